@@ -0,0 +1,217 @@
+package assetsgen
+
+import (
+	"errors"
+	"image"
+	"math"
+	"slices"
+	"sync"
+
+	"github.com/anthonynsimon/bild/transform"
+)
+
+var ErrInvalidAdaptiveIconShape = errors.New("invalid adaptive icon shape")
+
+// The shapes the launchers mask the adaptive icons with, and the safe zone that is never clipped
+type AdaptiveIconShape string
+
+const (
+	// The 66dp circle that every launcher shows
+	AdaptiveIconShapeSafeZone      AdaptiveIconShape = "safe-zone"
+	AdaptiveIconShapeCircle        AdaptiveIconShape = "circle"
+	AdaptiveIconShapeSquircle      AdaptiveIconShape = "squircle"
+	AdaptiveIconShapeRoundedSquare AdaptiveIconShape = "rounded-square"
+	AdaptiveIconShapeTeardrop      AdaptiveIconShape = "teardrop"
+)
+
+var AdaptiveIconShapes = []AdaptiveIconShape{
+	AdaptiveIconShapeSafeZone,
+	AdaptiveIconShapeCircle,
+	AdaptiveIconShapeSquircle,
+	AdaptiveIconShapeRoundedSquare,
+	AdaptiveIconShapeTeardrop,
+}
+
+func ParseAdaptiveIconShape(s string) (AdaptiveIconShape, error) {
+	shape := AdaptiveIconShape(s)
+	if !slices.Contains(AdaptiveIconShapes, shape) {
+		return "", ErrInvalidAdaptiveIconShape
+	}
+	return shape, nil
+}
+
+const (
+	// The adaptive icon layers are 108dp, the launchers show the inner 72dp through the mask
+	adaptiveIconLayerDp    = 108
+	adaptiveIconViewportDp = 72
+	adaptiveIconSafeZoneDp = 66
+
+	// The logo is scaled to the safe zone size by default, see [androidAdaptiveAppIconLogoDpisV26]
+	adaptiveIconLogoDp = adaptiveIconSafeZoneDp
+
+	// The superellipse exponent that matches the AOSP squircle mask path
+	squircleExponent = 3.1
+	// The corner radius of the AOSP rounded square mask path as percentage of the half size
+	roundedSquareCornerRatio = 0.16
+	// The corner radius of the bottom right corner of the AOSP teardrop mask path, the other corners are fully round
+	teardropCornerRatio = 0.3
+
+	// The logo is analyzed at most at this size, that's finer than a pixel at the biggest (xxxhdpi) layer
+	safeZoneAnalysisMaxSize = 512
+)
+
+// The gauge of the shape: for a point in dp relative to the center of the layer (y down), the scale the shape
+// should be to pass through the point. The point is inside the shape if it's <= 1, all the shapes are convex
+func (s AdaptiveIconShape) gauge(x, y float64) float64 {
+	const viewportHalf = adaptiveIconViewportDp / 2.0
+
+	switch s {
+	case AdaptiveIconShapeSafeZone:
+		return math.Hypot(x, y) / (adaptiveIconSafeZoneDp / 2.0)
+	case AdaptiveIconShapeCircle:
+		return math.Hypot(x, y) / viewportHalf
+	case AdaptiveIconShapeSquircle:
+		return math.Pow(math.Pow(math.Abs(x), squircleExponent)+math.Pow(math.Abs(y), squircleExponent), 1/squircleExponent) / viewportHalf
+	case AdaptiveIconShapeRoundedSquare:
+		return roundedSquareGauge(x, y, viewportHalf, roundedSquareCornerRatio)
+	case AdaptiveIconShapeTeardrop:
+		if x > 0 && y > 0 {
+			return roundedSquareGauge(x, y, viewportHalf, teardropCornerRatio)
+		}
+		return roundedSquareGauge(x, y, viewportHalf, 1)
+	default:
+		return math.Inf(1)
+	}
+}
+
+// The gauge of a square with half size [half] and corner radius [cornerRatio]*[half], 1 is a circle
+func roundedSquareGauge(x, y, half, cornerRatio float64) float64 {
+	x, y = math.Abs(x), math.Abs(y)
+
+	// the scale of the square without the rounded corners
+	t := max(x, y) / half
+
+	b := half * (1 - cornerRatio) // the half size of the inner square the corner circles are centered on
+	r := half * cornerRatio
+	if min(x, y) <= t*b || r == 0 {
+		return t
+	}
+
+	// on the corner: (x - t*b)^2 + (y - t*b)^2 = (t*r)^2, the smallest positive root is where the scaled corner passes
+	qa := 2*b*b - r*r
+	qb := -2 * b * (x + y)
+	qc := x*x + y*y
+	if qa == 0 {
+		return max(t, qc/(-qb))
+	}
+
+	sq := math.Sqrt(max(qb*qb-4*qa*qc, 0))
+	r1, r2 := (-qb-sq)/(2*qa), (-qb+sq)/(2*qa)
+	root := min(r1, r2)
+	if root <= 0 {
+		root = max(r1, r2)
+	}
+	return max(t, root)
+}
+
+type SafeZoneReport struct {
+	Shape AdaptiveIconShape
+
+	// Between [0..1] the share of the logo (weighted by alpha) outside the shape, that's clipped by the launcher
+	// or, for the safe zone, might be clipped by some launchers
+	OutsideShare float64
+
+	// The scale of the logo that fits it inside the shape exactly. Less than 1 if it overflows
+	FitScale float64
+}
+
+func (r SafeZoneReport) Fits() bool {
+	return r.OutsideShare == 0
+}
+
+// Analyzes the square logo as it's placed on the adaptive icon foreground layer, scaled to the 66dp safe zone size
+// and centered on the 108dp layer, against the safe zone and every launcher mask shape
+func AnalyzeAdaptiveIconSafeZone(logo image.Image) []SafeZoneReport {
+	img := safeZoneAnalysisImage(logo)
+
+	reports := make([]SafeZoneReport, len(AdaptiveIconShapes))
+	wg := sync.WaitGroup{}
+	for i, shape := range AdaptiveIconShapes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reports[i] = analyzeShape(img, shape)
+		}()
+	}
+	wg.Wait()
+	return reports
+}
+
+func safeZoneAnalysisImage(logo image.Image) *image.NRGBA {
+	img := asNRGBA(logo)
+	if size := max(img.Rect.Dx(), img.Rect.Dy()); size > safeZoneAnalysisMaxSize {
+		// the box filter spreads the alpha by a pixel at most, so the analysis stays on the safe side
+		w := img.Rect.Dx() * safeZoneAnalysisMaxSize / size
+		h := img.Rect.Dy() * safeZoneAnalysisMaxSize / size
+		img = asNRGBA(transform.Resize(img, max(w, 1), max(h, 1), transform.Box))
+	}
+	return img
+}
+
+// Scales the adaptive logo assets so the logo fits the shape exactly, bigger or smaller than the default 66dp
+func adaptiveIconAutoFitDpis(logo image.Image, shape AdaptiveIconShape, logoDpis []asset) []asset {
+	scale := analyzeShape(safeZoneAnalysisImage(logo), shape).FitScale
+
+	scaled := make([]asset, len(logoDpis))
+	for i, v := range logoDpis {
+		dpi := v.(androidAppIconDpiAsset)
+		dpi.size = max(int(math.Floor(float64(dpi.size)*scale)), 1)
+		scaled[i] = dpi
+	}
+	return scaled
+}
+
+func analyzeShape(img *image.NRGBA, shape AdaptiveIconShape) SafeZoneReport {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	// the logo is squared before it's placed, a non square image keeps its aspect in the square
+	dpPerPx := adaptiveIconLogoDp / float64(max(w, h))
+
+	var total, outside, maxGauge float64
+	for y := range h {
+		row := img.Pix[y*img.Stride : y*img.Stride+w*4]
+		for x := range w {
+			a := float64(row[x*4+3])
+			if a == 0 {
+				continue
+			}
+
+			g := shape.gauge((float64(x)+0.5-float64(w)/2)*dpPerPx, (float64(y)+0.5-float64(h)/2)*dpPerPx)
+			total += a
+			if g > 1 {
+				outside += a
+			}
+			maxGauge = max(maxGauge, g)
+		}
+	}
+
+	report := SafeZoneReport{Shape: shape, FitScale: adaptiveIconMaxLogoScale}
+	if total == 0 {
+		return report
+	}
+
+	report.OutsideShare = outside / total
+	if maxGauge > 0 {
+		report.FitScale = min(1/maxGauge, adaptiveIconMaxLogoScale)
+	}
+	return report
+}
+
+// The logo can't be bigger than the layer
+const adaptiveIconMaxLogoScale = float64(adaptiveIconLayerDp) / adaptiveIconLogoDp
+
+// Builds the logo the same way [GenerateAppIconForAndroidFromSource] does and analyzes it, see [AnalyzeAdaptiveIconSafeZone]
+func AnalyzeAdaptiveAppIcon(src *SourceImage, option AndroidAppIconOptions) []SafeZoneReport {
+	return AnalyzeAdaptiveIconSafeZone(
+		src.logo(option.TrimWhiteSpace, option.Trim, option.OpticalCentering, option.Padding, option.AlphaThreshold, option.MaskColor),
+	)
+}
@@ -1,8 +1,127 @@
 package assetsgen
 
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidAndroidResourceName = errors.New("invalid android resource name")
+
 type AndroidFolderName string
 
 const (
 	AndroidFolderMipmap   AndroidFolderName = "mipmap"
 	AndroidFolderDrawable AndroidFolderName = "drawable"
 )
+
+// The resource names end up as the fields of the R class, so they can't be Java keywords or literals
+var javaKeywords = map[string]bool{
+	"abstract": true, "assert": true, "boolean": true, "break": true, "byte": true, "case": true, "catch": true,
+	"char": true, "class": true, "const": true, "continue": true, "default": true, "do": true, "double": true,
+	"else": true, "enum": true, "extends": true, "false": true, "final": true, "finally": true, "float": true,
+	"for": true, "goto": true, "if": true, "implements": true, "import": true, "instanceof": true, "int": true,
+	"interface": true, "long": true, "native": true, "new": true, "null": true, "package": true, "private": true,
+	"protected": true, "public": true, "return": true, "short": true, "static": true, "strictfp": true,
+	"super": true, "switch": true, "synchronized": true, "this": true, "throw": true, "throws": true,
+	"transient": true, "true": true, "try": true, "void": true, "volatile": true, "while": true, "_": true,
+}
+
+// Checks [name] against the Android resource naming rules: lowercase letters, digits and underscores, not starting
+// with a digit and not a Java keyword. The error tells the broken rule and a valid alternative
+func ValidateAndroidResourceName(name string) error {
+	invalid := func(reason string) error {
+		return fmt.Errorf("%w %q: %s, e.g: %q", ErrInvalidAndroidResourceName, name, reason, SanitizeAndroidResourceName(name))
+	}
+
+	if name == "" {
+		return fmt.Errorf("%w: the name is empty", ErrInvalidAndroidResourceName)
+	}
+	for _, r := range name {
+		if !isAndroidResourceNameRune(r) {
+			return invalid(fmt.Sprintf("%q is not allowed, only lowercase letters, digits and underscores", r))
+		}
+	}
+	if isDigit(name[0]) {
+		return invalid("it starts with a digit")
+	}
+	if javaKeywords[name] {
+		return invalid("it is a Java keyword")
+	}
+	return nil
+}
+
+// Turns [name] into a valid Android resource name, see [ValidateAndroidResourceName].
+// e.g: "My-Icon 2" to "my_icon_2", "2x" to "res_2x" and "class" to "class_". The valid names are returned as they are
+func SanitizeAndroidResourceName(name string) string {
+	if isValidAndroidResourceName(name) {
+		return name
+	}
+
+	var b strings.Builder
+	replaced := false
+	for _, r := range strings.ToLower(name) {
+		if isAndroidResourceNameRune(r) {
+			b.WriteRune(r)
+			replaced = false
+			continue
+		}
+		// a run of invalid characters becomes a single underscore
+		if !replaced {
+			b.WriteByte('_')
+			replaced = true
+		}
+	}
+
+	sanitized := strings.Trim(b.String(), "_")
+	switch {
+	case sanitized == "":
+		return "res"
+	case isDigit(sanitized[0]):
+		return "res_" + sanitized
+	case javaKeywords[sanitized]:
+		return sanitized + "_"
+	}
+	return sanitized
+}
+
+func isValidAndroidResourceName(name string) bool {
+	if name == "" || isDigit(name[0]) || javaKeywords[name] {
+		return false
+	}
+	for _, r := range name {
+		if !isAndroidResourceNameRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// The name of the resources generated from [src]: [name], or the name of the source image when empty.
+// An invalid name is sanitized with [sanitize], otherwise it fails with [ErrInvalidAndroidResourceName]
+func androidResourceName(src *SourceImage, name string, sanitize bool) (string, error) {
+	if name == "" {
+		name = src.imgNameWithoutExt
+	}
+	if sanitize {
+		return SanitizeAndroidResourceName(name), nil
+	}
+	return name, ValidateAndroidResourceName(name)
+}
+
+// A reference to a resource from the XML files, e.g: @mipmap/ic_launcher_foreground
+func androidResourceRef(resType, name string) (string, error) {
+	err := ValidateAndroidResourceName(name)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprint("@", resType, "/", name), nil
+}
+
+func isAndroidResourceNameRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_'
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
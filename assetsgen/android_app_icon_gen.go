@@ -2,8 +2,7 @@ package assetsgen
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
+	"path"
 	"strings"
 	"sync"
 
@@ -190,35 +189,63 @@ type AndroidAppIconOptions struct {
 	// removes the white spaces from the edges of the logo
 	TrimWhiteSpace bool
 
+	// The background color to trim and key out, e.g: for JPG logos on white. Transparent only by default
+	Trim TrimOptions
+
+	// Between [0..1] moves the logo toward its visual center (the alpha weighted centroid) instead of the center
+	// of its bounding box. 0 to disable, 0.5 suits most triangles and letters like "L"
+	OpticalCentering float64
+
+	// Scales the logo of the adaptive icon to fit this shape exactly instead of using [AndroidAppIconOptions.Padding] as is.
+	// Empty to disable, see [AnalyzeAdaptiveAppIcon] to check how the logo fits
+	AutoFit AdaptiveIconShape
+
 	MaskColor *colorful.Color
 
 	OutputFileName string
+
+	// Turns an invalid [AndroidAppIconOptions.OutputFileName] into a valid Android resource name instead of failing with
+	// [ErrInvalidAndroidResourceName], see [SanitizeAndroidResourceName]
+	SanitizeName bool
+
+	// The filter and the sharpening used to resize to the asset sizes
+	Resize ResizeOptions
+
+	// Where the files are written to. Nil falls back to [RootFolderName] in the working directory, see [NewDirSink]
+	Output OutputSink
 }
 
 func GenerateAppIconForAndroid(imagePath string, option AndroidAppIconOptions) error {
-	logoImage, err := newImageInfo(
-		imagePath,
-		filepath.Join(PlatformTypeAndroid, "res"),
-	)
+	src, err := LoadSourceImage(imagePath)
 	if err != nil {
 		return err
 	}
-	defer logoImage.rootDir.Close()
+	return GenerateAppIconForAndroidFromSource(src, option)
+}
 
-	pad := calPadding(logoImage.img, option.Padding)
+func GenerateAppIconForAndroidFromSource(src *SourceImage, option AndroidAppIconOptions) error {
+	name, err := androidResourceName(src, option.OutputFileName, option.SanitizeName)
+	if err != nil {
+		return err
+	}
 
-	logoImage.
-		If(option.TrimWhiteSpace, logoImage.TrimWhiteSpace).
-		SquareImageWithEmptyPixels(pad).
-		If(option.AlphaThreshold >= 0, func() *imageInfo { return logoImage.RemoveAlphaOnThreshold(option.AlphaThreshold) }).
-		If(option.MaskColor != nil, func() *imageInfo { return logoImage.ConvertNoneOpaqueToColor(*option.MaskColor) })
+	logoImage, err := newImageInfoFromSource(
+		src,
+		src.logo(option.TrimWhiteSpace, option.Trim, option.OpticalCentering, option.Padding, option.AlphaThreshold, option.MaskColor),
+		option.Output,
+		path.Join(PlatformTypeAndroid, "res"),
+	)
+	if err != nil {
+		return err
+	}
+	defer logoImage.CloseOutput()
+	logoImage.SetResizeOptions(option.Resize.withDefaultSpace(ResizeSpaceLinear)).
+		If(option.Trim.KeyOut, logoImage.EncodeWithAlpha)
 
-	bgImage := new(imageInfo)
-	if _, ok := option.BgIcon.(solidColorBackground); !ok {
-		bgImage, err = option.BgIcon.generateImgInfo(logoImage)
-		if err != nil {
-			return err
-		}
+	// generated once and shared between the legacy and adaptive icons, both only read from it
+	bgImage, err := option.BgIcon.generateImgInfo(logoImage)
+	if err != nil {
+		return err
 	}
 
 	w := sync.WaitGroup{}
@@ -230,13 +257,6 @@ func GenerateAppIconForAndroid(imagePath string, option AndroidAppIconOptions) e
 	go func() {
 		defer w.Done()
 
-		if !bgImage.IsValid() {
-			bgImage, legacyAppIconError = option.BgIcon.generateImgInfo(logoImage)
-			if legacyAppIconError != nil {
-				return
-			}
-		}
-
 		legacyAppIconError = generateLegacyAppIcon(
 			*logoImage,
 			*bgImage,
@@ -244,16 +264,21 @@ func GenerateAppIconForAndroid(imagePath string, option AndroidAppIconOptions) e
 			option.AlphaThreshold,
 			androidAppIconDpisLegacyLogo(string(option.FolderName)),
 			androidAppIconDpisLegacyLayer(string(option.FolderName)),
-			option.OutputFileName,
+			name,
 		)
 	}()
 
+	adaptiveLogoDpis := androidAdaptiveAppIconLogoDpisV26(string(option.FolderName))
+	if option.AutoFit != "" {
+		adaptiveLogoDpis = adaptiveIconAutoFitDpis(logoImage.img, option.AutoFit, adaptiveLogoDpis)
+	}
+
 	go func() {
 		defer w.Done()
 
-		var solidColor *colorful.Color
+		var solidColor *solidColorBackground
 		if s, ok := option.BgIcon.(solidColorBackground); ok {
-			solidColor = &s.color
+			solidColor = &s
 		}
 
 		adaptiveAppIconError = generateAdaptiveAppIcon(
@@ -261,8 +286,8 @@ func GenerateAppIconForAndroid(imagePath string, option AndroidAppIconOptions) e
 			*bgImage,
 			solidColor,
 			androidAdaptiveAppIconLayerDpisV26(string(option.FolderName)),
-			androidAdaptiveAppIconLogoDpisV26(string(option.FolderName)),
-			option.OutputFileName,
+			adaptiveLogoDpis,
+			name,
 		)
 	}()
 
@@ -303,7 +328,7 @@ func generateLegacyAppIcon(
 	return nil
 }
 
-func generateAdaptiveAppIcon(logoImage imageInfo, bgImage imageInfo, solidColor *colorful.Color, androidAdaptiveAppIconLayerDpisV26 []asset, androidAdaptiveAppIconLogoDpisV26 []asset, outputFileName string) error {
+func generateAdaptiveAppIcon(logoImage imageInfo, bgImage imageInfo, solidColor *solidColorBackground, androidAdaptiveAppIconLayerDpisV26 []asset, androidAdaptiveAppIconLogoDpisV26 []asset, outputFileName string) error {
 	err := generateIcLauncherXml(logoImage, outputFileName, solidColor)
 	if err != nil {
 		return err
@@ -315,16 +340,10 @@ func generateAdaptiveAppIcon(logoImage imageInfo, bgImage imageInfo, solidColor
 		SetAssets(androidAdaptiveAppIconLayerDpisV26).
 		CenterCanvasForAssets()
 
-	shouldUseAssetName := len(outputFileName) == 0
 	foregroundName := fmt.Sprint(outputFileName, "_foreground")
 	monochromeName := fmt.Sprint(outputFileName, "_monochrome")
 
 	for _, logo := range *logos {
-		if shouldUseAssetName {
-			foregroundName = fmt.Sprint(logo.imgNameWithoutExt, "_foreground")
-			monochromeName = fmt.Sprint(logo.imgNameWithoutExt, "_monochrome")
-		}
-
 		err := logo.SaveWithCustomName(foregroundName)
 		if err != nil {
 			return err
@@ -343,9 +362,6 @@ func generateAdaptiveAppIcon(logoImage imageInfo, bgImage imageInfo, solidColor
 
 		backgroundName := fmt.Sprint(outputFileName, "_background")
 		for _, bg := range *bgs {
-			if shouldUseAssetName {
-				backgroundName = fmt.Sprint(bg.imgNameWithoutExt, "_background")
-			}
 			err := bg.SaveWithCustomName(backgroundName)
 			if err != nil {
 				return err
@@ -356,7 +372,7 @@ func generateAdaptiveAppIcon(logoImage imageInfo, bgImage imageInfo, solidColor
 	return nil
 }
 
-func generateIcLauncherXml(logoImage imageInfo, outputFileName string, solidColor *colorful.Color) error {
+func generateIcLauncherXml(logoImage imageInfo, outputFileName string, solidColor *solidColorBackground) error {
 	sb := strings.Builder{}
 
 	sb.WriteString(`<?xml version="1.0" encoding="utf-8" ?>`)
@@ -365,17 +381,30 @@ func generateIcLauncherXml(logoImage imageInfo, outputFileName string, solidColo
 	sb.WriteString(`<adaptive-icon xmlns:android="http://schemas.android.com/apk/res/android">`)
 	sb.WriteRune('\n')
 
-	if solidColor == nil {
-		sb.WriteString(fmt.Sprint(`    <background android:drawable="@mipmap/`, outputFileName, `_background" />`))
-	} else {
-		sb.WriteString(fmt.Sprint(`    <background android:drawable="@color/`, outputFileName, `_background" />`))
+	backgroundType := "mipmap"
+	if solidColor != nil {
+		backgroundType = "color"
 	}
+	background, err := androidResourceRef(backgroundType, fmt.Sprint(outputFileName, "_background"))
+	if err != nil {
+		return err
+	}
+	foreground, err := androidResourceRef("mipmap", fmt.Sprint(outputFileName, "_foreground"))
+	if err != nil {
+		return err
+	}
+	monochrome, err := androidResourceRef("mipmap", fmt.Sprint(outputFileName, "_monochrome"))
+	if err != nil {
+		return err
+	}
+
+	sb.WriteString(fmt.Sprint(`    <background android:drawable="`, background, `" />`))
 	sb.WriteRune('\n')
 
-	sb.WriteString(fmt.Sprint(`    <foreground android:drawable="@mipmap/`, outputFileName, `_foreground" />`))
+	sb.WriteString(fmt.Sprint(`    <foreground android:drawable="`, foreground, `" />`))
 	sb.WriteRune('\n')
 
-	sb.WriteString(fmt.Sprint(`    <monochrome android:drawable="@mipmap/`, outputFileName, `_monochrome" />`))
+	sb.WriteString(fmt.Sprint(`    <monochrome android:drawable="`, monochrome, `" />`))
 	sb.WriteRune('\n')
 
 	sb.WriteString(`</adaptive-icon>`)
@@ -383,18 +412,8 @@ func generateIcLauncherXml(logoImage imageInfo, outputFileName string, solidColo
 
 	ic_launcher_xml := sb.String()
 
-	dir := filepath.Join(logoImage.saveDirPath, "mipmap-anydpi-v26")
-	err := logoImage.rootDir.Mkdir(dir, os.ModePerm)
-	if err != nil && !os.IsExist(err) {
-		return err
-	}
-
-	file, err := logoImage.rootDir.Create(filepath.Join(dir, fmt.Sprint(outputFileName, ".xml")))
-	if err != nil {
-		return err
-	}
-
-	_, err = file.WriteString(ic_launcher_xml)
+	name := path.Join(logoImage.saveDirPath, "mipmap-anydpi-v26", fmt.Sprint(outputFileName, ".xml"))
+	err = writeFile(logoImage.output, name, []byte(ic_launcher_xml))
 	if err != nil {
 		return err
 	}
@@ -409,7 +428,7 @@ func generateIcLauncherXml(logoImage imageInfo, outputFileName string, solidColo
 	return nil
 }
 
-func generateIcBackgroundSolidColorXmlValueColorFile(logoImage imageInfo, outputFileName string, solidColor colorful.Color) error {
+func generateIcBackgroundSolidColorXmlValueColorFile(logoImage imageInfo, outputFileName string, solidColor solidColorBackground) error {
 	sb := strings.Builder{}
 
 	sb.WriteString(`<?xml version="1.0" encoding="utf-8" ?>`)
@@ -418,7 +437,7 @@ func generateIcBackgroundSolidColorXmlValueColorFile(logoImage imageInfo, output
 	sb.WriteString(`<resources>`)
 	sb.WriteRune('\n')
 
-	sb.WriteString(fmt.Sprint(`    <color name="`, outputFileName, `_background">`, solidColor.Hex(), `</color>`))
+	sb.WriteString(fmt.Sprint(`    <color name="`, outputFileName, `_background">`, androidColorHex(solidColor.color, solidColor.alpha), `</color>`))
 	sb.WriteRune('\n')
 
 	sb.WriteString(`</resources>`)
@@ -426,21 +445,6 @@ func generateIcBackgroundSolidColorXmlValueColorFile(logoImage imageInfo, output
 
 	ic_launcher_background_xml := sb.String()
 
-	dir := filepath.Join(logoImage.saveDirPath, "values")
-	err := logoImage.rootDir.Mkdir(dir, os.ModePerm)
-	if err != nil && !os.IsExist(err) {
-		return err
-	}
-
-	file, err := logoImage.rootDir.Create(filepath.Join(dir, fmt.Sprint(outputFileName, "_background.xml")))
-	if err != nil {
-		return err
-	}
-
-	_, err = file.WriteString(ic_launcher_background_xml)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	name := path.Join(logoImage.saveDirPath, "values", fmt.Sprint(outputFileName, "_background.xml"))
+	return writeFile(logoImage.output, name, []byte(ic_launcher_background_xml))
 }
@@ -3,7 +3,7 @@ package assetsgen
 import (
 	"fmt"
 	"math"
-	"path/filepath"
+	"path"
 )
 
 type AndroidImageAssetsOptions struct {
@@ -11,21 +11,50 @@ type AndroidImageAssetsOptions struct {
 
 	// removes the white spaces from the edges of the logo
 	TrimWhiteSpace bool
+
+	// The background color to trim and key out, e.g: for JPG logos on white. Transparent only by default
+	Trim TrimOptions
+
+	// The name of the files without the extension. Empty keeps the name of the source image
+	OutputFileName string
+
+	// Turns an invalid [AndroidImageAssetsOptions.OutputFileName] into a valid Android resource name instead of failing with
+	// [ErrInvalidAndroidResourceName], see [SanitizeAndroidResourceName]
+	SanitizeName bool
+
+	// The filter and the sharpening used to resize to the asset sizes
+	Resize ResizeOptions
+
+	// Where the files are written to. Nil falls back to [RootFolderName] in the working directory, see [NewDirSink]
+	Output OutputSink
 }
 
 func GenerateImageAssetsForAndroid(imagePath string, option AndroidImageAssetsOptions) error {
-	imgInfo, err := newImageInfo(
-		imagePath,
-		filepath.Join(PlatformTypeAndroid, "res"),
-	)
+	src, err := LoadSourceImage(imagePath)
+	if err != nil {
+		return err
+	}
+	return GenerateImageAssetsForAndroidFromSource(src, option)
+}
+
+func GenerateImageAssetsForAndroidFromSource(src *SourceImage, option AndroidImageAssetsOptions) error {
+	name, err := androidResourceName(src, option.OutputFileName, option.SanitizeName)
 	if err != nil {
 		return err
 	}
-	defer imgInfo.rootDir.Close()
 
-	if option.TrimWhiteSpace {
-		imgInfo.TrimWhiteSpace()
+	imgInfo, err := newImageInfoFromSource(
+		src,
+		src.trimmed(option.TrimWhiteSpace, option.Trim),
+		option.Output,
+		path.Join(PlatformTypeAndroid, "res"),
+	)
+	if err != nil {
+		return err
 	}
+	defer imgInfo.CloseOutput()
+	imgInfo.SetResizeOptions(option.Resize.withDefaultSpace(ResizeSpaceSrgb)).
+		If(option.Trim.KeyOut, imgInfo.EncodeWithAlpha)
 
 	imgBounds := imgInfo.img.Bounds()
 	androidScreenDpis := generateAndroidScreenDpis(imgBounds.Dx(), imgBounds.Dy(), string(option.FolderName))
@@ -33,7 +62,7 @@ func GenerateImageAssetsForAndroid(imagePath string, option AndroidImageAssetsOp
 	err = imgInfo.
 		SplitPerAsset(androidScreenDpis).
 		ResizeForAssets().
-		Save()
+		SaveWithCustomName(name)
 	if err != nil {
 		return err
 	}
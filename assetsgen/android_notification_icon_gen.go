@@ -3,7 +3,7 @@ package assetsgen
 import (
 	"fmt"
 	"image/color"
-	"path/filepath"
+	"path"
 )
 
 func androidNotificationIconDpis(androidFolderName string) []asset {
@@ -57,27 +57,60 @@ type AndroidNotificationIconOptions struct {
 	// removes the white spaces from the edges of the logo
 	TrimWhiteSpace bool
 
+	// The background color to trim and key out, e.g: for JPG logos on white. Transparent only by default
+	Trim TrimOptions
+
+	// Between [0..1] moves the logo toward its visual center (the alpha weighted centroid) instead of the center
+	// of its bounding box. 0 to disable, 0.5 suits most triangles and letters like "L"
+	OpticalCentering float64
+
 	OutputFileName string
+
+	// Turns an invalid [AndroidNotificationIconOptions.OutputFileName] into a valid Android resource name instead of failing with
+	// [ErrInvalidAndroidResourceName], see [SanitizeAndroidResourceName]
+	SanitizeName bool
+
+	// The filter and the sharpening used to resize to the asset sizes
+	Resize ResizeOptions
+
+	// Where the files are written to. Nil falls back to [RootFolderName] in the working directory, see [NewDirSink]
+	Output OutputSink
 }
 
 func GenerateNotificationIconForAndroid(imagePath string, option AndroidNotificationIconOptions) error {
-	logoImage, err := newImageInfo(
-		imagePath,
-		filepath.Join(PlatformTypeAndroid, "res"),
+	src, err := LoadSourceImage(imagePath)
+	if err != nil {
+		return err
+	}
+	return GenerateNotificationIconForAndroidFromSource(src, option)
+}
+
+func GenerateNotificationIconForAndroidFromSource(src *SourceImage, option AndroidNotificationIconOptions) error {
+	name, err := androidResourceName(src, option.OutputFileName, option.SanitizeName)
+	if err != nil {
+		return err
+	}
+
+	logoImage, err := newImageInfoFromSource(
+		src,
+		src.trimmed(option.TrimWhiteSpace, option.Trim),
+		option.Output,
+		path.Join(PlatformTypeAndroid, "res"),
 	)
 	if err != nil {
 		return err
 	}
-	defer logoImage.rootDir.Close()
+	defer logoImage.CloseOutput()
+	logoImage.SetResizeOptions(option.Resize.withDefaultSpace(ResizeSpaceLinear)).
+		If(option.Trim.KeyOut, logoImage.EncodeWithAlpha)
 
 	err = logoImage.
-		If(option.TrimWhiteSpace, logoImage.TrimWhiteSpace).
 		If(option.AlphaThreshold >= 0, func() *imageInfo { return logoImage.RemoveAlphaOnThreshold(option.AlphaThreshold) }).
 		ConvertNoneOpaqueToColor(color.RGBA{R: 255, G: 255, B: 255, A: 255}).
-		SquareImageWithEmptyPixels(0).
+		SquareImageWithOpticalCenter(0, option.OpticalCentering).
 		SplitPerAsset(androidNotificationIconDpis(string(option.FolderName))).
 		ResizeForAssets().
-		SaveWithCustomName(option.OutputFileName)
+		SaveWithCustomName(name)
 
 	if err != nil {
 		return err
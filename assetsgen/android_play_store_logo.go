@@ -1,7 +1,7 @@
 package assetsgen
 
 import (
-	"path/filepath"
+	"path"
 
 	"github.com/lucasb-eyer/go-colorful"
 )
@@ -18,27 +18,45 @@ type AndroidGooglePlayLogoOptions struct {
 	// removes the white spaces from the edges of the logo
 	TrimWhiteSpace bool
 
+	// The background color to trim and key out, e.g: for JPG logos on white. Transparent only by default
+	Trim TrimOptions
+
+	// Between [0..1] moves the logo toward its visual center (the alpha weighted centroid) instead of the center
+	// of its bounding box. 0 to disable, 0.5 suits most triangles and letters like "L"
+	OpticalCentering float64
+
 	MaskColor *colorful.Color
 
 	OutputFileName string
+
+	// The filter and the sharpening used to resize to the asset sizes
+	Resize ResizeOptions
+
+	// Where the files are written to. Nil falls back to [RootFolderName] in the working directory, see [NewDirSink]
+	Output OutputSink
 }
 
 func GenerateAndroidGooglePlayLogo(imagePath string, option AndroidGooglePlayLogoOptions) error {
-	logoImage, err := newImageInfo(
-		imagePath,
-		filepath.Join(PlatformTypeAndroid, "main"),
-	)
+	src, err := LoadSourceImage(imagePath)
 	if err != nil {
 		return err
 	}
-	defer logoImage.rootDir.Close()
-
-	pad := calPadding(logoImage.img, option.Padding)
+	return GenerateAndroidGooglePlayLogoFromSource(src, option)
+}
 
-	logoImage.
-		If(option.TrimWhiteSpace, logoImage.TrimWhiteSpace).
-		SquareImageWithEmptyPixels(pad).
-		If(option.MaskColor != nil, func() *imageInfo { return logoImage.ConvertNoneOpaqueToColor(*option.MaskColor) })
+func GenerateAndroidGooglePlayLogoFromSource(src *SourceImage, option AndroidGooglePlayLogoOptions) error {
+	logoImage, err := newImageInfoFromSource(
+		src,
+		src.logo(option.TrimWhiteSpace, option.Trim, option.OpticalCentering, option.Padding, -1, option.MaskColor),
+		option.Output,
+		path.Join(PlatformTypeAndroid, "main"),
+	)
+	if err != nil {
+		return err
+	}
+	defer logoImage.CloseOutput()
+	logoImage.SetResizeOptions(option.Resize.withDefaultSpace(ResizeSpaceLinear)).
+		If(option.Trim.KeyOut, logoImage.EncodeWithAlpha)
 
 	bgImage, err := option.BgIcon.generateImgInfo(logoImage)
 	if err != nil {
@@ -47,10 +65,12 @@ func GenerateAndroidGooglePlayLogo(imagePath string, option AndroidGooglePlayLog
 
 	bgImage.asset = androidGooglePlayLogoDpiAsset{
 		dpiName: "main",
-		Size:    512,
+		Size:    googlePlayLogoSize,
 	}
 
+	// Google Play asks for 32-bit PNG
 	err = bgImage.
+		EncodeAsPNG32().
 		StackWithNoAlpha(option.AlphaThreshold, logoImage).
 		ResizeForAsset().
 		SaveWithCustomName(option.OutputFileName)
@@ -0,0 +1,102 @@
+package assetsgen
+
+import (
+	"errors"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+func TestAndroidResourceName(t *testing.T) {
+	tests := []struct {
+		name          string
+		wantValid     bool
+		wantSanitized string
+	}{
+		{name: "ic_launcher", wantValid: true, wantSanitized: "ic_launcher"},
+		{name: "My-Icon 2", wantSanitized: "my_icon_2"},
+		{name: "2abc", wantSanitized: "res_2abc"},
+		{name: "class", wantSanitized: "class_"},
+		{name: "ÄÖ", wantSanitized: "res"},
+		{name: "", wantSanitized: "res"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAndroidResourceName(tt.name)
+			if tt.wantValid && err != nil {
+				t.Errorf("ValidateAndroidResourceName() = %v, want nil", err)
+			}
+			if !tt.wantValid && !errors.Is(err, ErrInvalidAndroidResourceName) {
+				t.Errorf("ValidateAndroidResourceName() = %v, want %v", err, ErrInvalidAndroidResourceName)
+			}
+
+			got := SanitizeAndroidResourceName(tt.name)
+			if got != tt.wantSanitized {
+				t.Errorf("SanitizeAndroidResourceName() = %q, want %q", got, tt.wantSanitized)
+			}
+			if err := ValidateAndroidResourceName(got); err != nil {
+				t.Errorf("the sanitized name is invalid: %v", err)
+			}
+		})
+	}
+}
+
+func TestAndroidResourceRef(t *testing.T) {
+	got, err := androidResourceRef("mipmap", "ic_launcher_foreground")
+	if err != nil || got != "@mipmap/ic_launcher_foreground" {
+		t.Errorf("androidResourceRef() = %q, %v, want %q", got, err, "@mipmap/ic_launcher_foreground")
+	}
+
+	_, err = androidResourceRef("mipmap", "My Icon_foreground")
+	if !errors.Is(err, ErrInvalidAndroidResourceName) {
+		t.Errorf("androidResourceRef() error = %v, want %v", err, ErrInvalidAndroidResourceName)
+	}
+}
+
+func TestAdaptiveIconXmlReferences(t *testing.T) {
+	src, err := NewSourceImage(solidNRGBA(64, 64, color.NRGBA{R: 51, G: 102, B: 204, A: 255}), "logo.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := NewMemorySink()
+	err = GenerateAppIconForAndroidFromSource(src, AndroidAppIconOptions{
+		BgIcon:         NewSolidColorBackground(colorful.Color{R: 1}),
+		AlphaThreshold: 0.5,
+		FolderName:     AndroidFolderMipmap,
+		OutputFileName: "My Icon",
+		SanitizeName:   true,
+		Output:         output,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const name = "android/res/mipmap-anydpi-v26/my_icon.xml"
+	data, ok := output.File(name)
+	if !ok {
+		t.Fatalf("%s was not generated", name)
+	}
+	for _, want := range []string{
+		`<background android:drawable="@color/my_icon_background" />`,
+		`<foreground android:drawable="@mipmap/my_icon_foreground" />`,
+		`<monochrome android:drawable="@mipmap/my_icon_monochrome" />`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("%s has no %s:\n%s", name, want, data)
+		}
+	}
+
+	err = GenerateAppIconForAndroidFromSource(src, AndroidAppIconOptions{
+		BgIcon:         NewSolidColorBackground(colorful.Color{R: 1}),
+		FolderName:     AndroidFolderMipmap,
+		OutputFileName: "My Icon",
+		Output:         NewMemorySink(),
+	})
+	if !errors.Is(err, ErrInvalidAndroidResourceName) {
+		t.Errorf("GenerateAppIconForAndroidFromSource() error = %v, want %v", err, ErrInvalidAndroidResourceName)
+	}
+}
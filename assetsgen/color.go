@@ -0,0 +1,73 @@
+package assetsgen
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+var ErrInvalidHexColor = errors.New("invalid hex color. e.g of valid colors #00F, #00F8, #0000FF, #0000FF80")
+
+// Parses "html" hex colors with an optional alpha channel: #RGB, #RGBA, #RRGGBB and #RRGGBBAA.
+// The returned alpha is between [0..1], 1 is fully opaque
+func ParseHexColor(s string) (colorful.Color, float64, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "#") {
+		return colorful.Color{}, 0, ErrInvalidHexColor
+	}
+	hex := s[1:]
+
+	// expand the short forms #RGB and #RGBA
+	if len(hex) == 3 || len(hex) == 4 {
+		sb := strings.Builder{}
+		for _, r := range hex {
+			sb.WriteRune(r)
+			sb.WriteRune(r)
+		}
+		hex = sb.String()
+	}
+
+	if len(hex) != 6 && len(hex) != 8 {
+		return colorful.Color{}, 0, ErrInvalidHexColor
+	}
+
+	// every channel is exactly two hex digits, unlike fmt.Sscanf that stops at the first invalid one
+	channels := [4]uint64{0, 0, 0, 255}
+	for i := 0; i < len(hex); i += 2 {
+		v, err := strconv.ParseUint(hex[i:i+2], 16, 8)
+		if err != nil {
+			return colorful.Color{}, 0, ErrInvalidHexColor
+		}
+		channels[i/2] = v
+	}
+
+	c := colorful.Color{R: float64(channels[0]) / 255, G: float64(channels[1]) / 255, B: float64(channels[2]) / 255}
+	alpha := float64(channels[3]) / 255
+
+	return c, alpha, nil
+}
+
+// [alpha] between [0..1], 1 is fully opaque
+func toNRGBA(c colorful.Color, alpha float64) color.NRGBA {
+	c = c.Clamped()
+	return color.NRGBA{
+		R: uint8(c.R*255 + 0.5),
+		G: uint8(c.G*255 + 0.5),
+		B: uint8(c.B*255 + 0.5),
+		A: uint8(math.Max(0, math.Min(1, alpha))*255 + 0.5),
+	}
+}
+
+// The android color resource format #AARRGGBB, or #RRGGBB when the color is opaque
+func androidColorHex(c colorful.Color, alpha float64) string {
+	nrgba := toNRGBA(c, alpha)
+	if nrgba.A == 255 {
+		return c.Hex()
+	}
+	return fmt.Sprintf("#%02x%02x%02x%02x", nrgba.A, nrgba.R, nrgba.G, nrgba.B)
+}
@@ -0,0 +1,46 @@
+package assetsgen
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseHexColor(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		alpha   float64
+		wantErr bool
+	}{
+		{in: "#0000FF", want: "#0000ff", alpha: 1},
+		{in: "#00F", want: "#0000ff", alpha: 1},
+		{in: "#00F8", want: "#0000ff", alpha: float64(0x88) / 255},
+		{in: " #ff000080 ", want: "#ff0000", alpha: float64(0x80) / 255},
+		{in: "#00000000", want: "#000000", alpha: 0},
+		{in: "#ff00008z", wantErr: true},
+		{in: "#ff0z00", wantErr: true},
+		{in: "#+f0000", wantErr: true},
+		{in: "#ff00", want: "#ffff00", alpha: 0},
+		{in: "ff0000", wantErr: true},
+		{in: "#ff00000", wantErr: true},
+		{in: "#ff0000ÄÖ", wantErr: true},
+		{in: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		c, alpha, err := ParseHexColor(tt.in)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidHexColor) {
+				t.Errorf("ParseHexColor(%q) error = %v, want %v", tt.in, err, ErrInvalidHexColor)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseHexColor(%q) unexpected error %v", tt.in, err)
+			continue
+		}
+		if c.Hex() != tt.want || alpha != tt.alpha {
+			t.Errorf("ParseHexColor(%q) = %s, %v, want %s, %v", tt.in, c.Hex(), alpha, tt.want, tt.alpha)
+		}
+	}
+}
@@ -26,12 +26,18 @@ const (
 	RootFolderName string = "assets_gen_out"
 )
 
-type asset interface {
+// A target the image is resized to, e.g: one density of the android icons
+type Asset interface {
 	Name() string
+	// The size of the asset for the image of size w,h
 	CalcSize(w, h int) (int, int)
+	// The folder the asset is saved in, empty for the root of the platform folder
 	DirName() string
 }
 
+// The generators' tables predate [Asset]
+type asset = Asset
+
 type BackgroundIcon interface {
 	generateImgInfo(logo *imageInfo) (*imageInfo, error)
 }
@@ -40,16 +46,27 @@ type gradientBackground struct {
 	table        GradientTable
 	degree       int
 	gradientType GradientType
+	space        GradientSpace
+
+	// between [0..1] as percentage of the width and height of the image
+	centerX float64
+	centerY float64
+
+	radial RadialGradientOptions
 }
 
 func (g gradientBackground) generateImgInfo(logo *imageInfo) (*imageInfo, error) {
-	bgImage := logo.Copy()
+	bgImage := logo.ShallowCopy()
 
 	switch g.gradientType {
 	case LinearGradient:
-		bgImage.LinearGradient(g.table, g.degree)
+		bgImage.LinearGradient(g.table, g.space, g.degree)
 	case RadialGradient:
-		bgImage.RadialGradient(g.table)
+		bgImage.RadialGradient(g.table, g.space, g.radial)
+	case ConicGradient:
+		bgImage.ConicGradient(g.table, g.space, g.degree, g.centerX, g.centerY)
+	case DiamondGradient:
+		bgImage.DiamondGradient(g.table, g.space, g.centerX, g.centerY)
 	}
 
 	return bgImage, nil
@@ -59,8 +76,42 @@ func NewLinearGradientBackground(table GradientTable, degree int) BackgroundIcon
 	return gradientBackground{table: table, degree: degree, gradientType: LinearGradient}
 }
 
-func NewRadialGradientBackground(table GradientTable) BackgroundIcon {
-	return gradientBackground{table: table, gradientType: RadialGradient}
+// Use [DefaultRadialGradientOptions] for a circle that touches the edges of the image
+func NewRadialGradientBackground(table GradientTable, option RadialGradientOptions) BackgroundIcon {
+	return gradientBackground{table: table, gradientType: RadialGradient, radial: option}
+}
+
+// [startDegree] the angle where the sweep starts, 0 points to the right and the sweep goes clockwise.
+// [centerX], [centerY] between [0..1] as percentage of the width and height of the image, 0.5 is the center of the image
+func NewConicGradientBackground(table GradientTable, startDegree int, centerX, centerY float64) BackgroundIcon {
+	return gradientBackground{
+		table:        table,
+		degree:       startDegree,
+		gradientType: ConicGradient,
+		centerX:      centerX,
+		centerY:      centerY,
+	}
+}
+
+// [centerX], [centerY] between [0..1] as percentage of the width and height of the image, 0.5 is the center of the image
+func NewDiamondGradientBackground(table GradientTable, centerX, centerY float64) BackgroundIcon {
+	return gradientBackground{
+		table:        table,
+		gradientType: DiamondGradient,
+		centerX:      centerX,
+		centerY:      centerY,
+	}
+}
+
+// Sets the color space the gradient colors are blended in, see [GradientSpaces].
+// The gradient backgrounds default to [GradientSpaceHcl]. Any other background is returned as is.
+func WithGradientSpace(bgIcon BackgroundIcon, space GradientSpace) BackgroundIcon {
+	g, ok := bgIcon.(gradientBackground)
+	if !ok {
+		return bgIcon
+	}
+	g.space = space
+	return g
 }
 
 type imageBackground struct {
@@ -68,7 +119,7 @@ type imageBackground struct {
 }
 
 func (i imageBackground) generateImgInfo(logo *imageInfo) (*imageInfo, error) {
-	bgImage := logo.Copy()
+	bgImage := logo.ShallowCopy()
 	img, err := imgio.Open(i.imagePath)
 	if err != nil {
 		return &imageInfo{}, err
@@ -77,9 +128,9 @@ func (i imageBackground) generateImgInfo(logo *imageInfo) (*imageInfo, error) {
 
 	logoBounds := logo.img.Bounds()
 
+	// the alpha is kept for the targets that allows transparency, the others will remove it when stacking the logo
 	bgImage.CropToSquare().
-		Resize(logoBounds.Dx(), logoBounds.Dy()).
-		RemoveAlpha()
+		Resize(logoBounds.Dx(), logoBounds.Dy())
 
 	return bgImage, nil
 }
@@ -91,14 +142,21 @@ func NewImageBackground(imagePath string) BackgroundIcon {
 
 type solidColorBackground struct {
 	color colorful.Color
+	// between [0..1], 1 is fully opaque
+	alpha float64
 }
 
 func (s solidColorBackground) generateImgInfo(logo *imageInfo) (*imageInfo, error) {
-	return logo.Copy().SoldiColor(s.color), nil
+	return logo.ShallowCopy().SoldiColor(toNRGBA(s.color, s.alpha)), nil
 }
 
 func NewSolidColorBackground(c colorful.Color) BackgroundIcon {
-	return solidColorBackground{c}
+	return solidColorBackground{color: c, alpha: 1}
+}
+
+// [alpha] between [0..1], 1 is fully opaque. The alpha is only kept for the targets that allows transparency
+func NewTranslucentSolidColorBackground(c colorful.Color, alpha float64) BackgroundIcon {
+	return solidColorBackground{color: c, alpha: alpha}
 }
 
 func IsFileExistsAndImage(filePath string) error {
@@ -128,7 +186,15 @@ func calPadding(img image.Image, padding float64) int {
 }
 
 func GetRootDir() (*os.Root, error) {
-	p := filepath.Join("./", RootFolderName)
+	return OpenOutputDir(RootFolderName)
+}
+
+// Creates the folder if it doesn't exist and opens it. Empty falls back to [RootFolderName] in the working directory
+func OpenOutputDir(dir string) (*os.Root, error) {
+	if dir == "" {
+		dir = RootFolderName
+	}
+	p := filepath.Clean(dir)
 	err := os.MkdirAll(p, os.ModePerm)
 	if err != nil {
 		return nil, err
@@ -141,13 +207,17 @@ func GetRootDir() (*os.Root, error) {
 	return rootDir, nil
 }
 
-func saveImage(root *os.Root, filename string, img image.Image, encoder imgio.Encoder) error {
-	f, err := root.Create(filename)
+func saveImage(output OutputSink, name string, img image.Image, encoder imgio.Encoder) error {
+	f, err := output.Create(name)
+	if err != nil {
+		return err
+	}
+	err = encoder(f, img)
 	if err != nil {
+		f.Close()
 		return err
 	}
-	defer f.Close()
-	return encoder(f, img)
+	return f.Close()
 }
 
 func splitPath(path string) []string {
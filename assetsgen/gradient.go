@@ -10,14 +10,151 @@ import (
 type GradientType string
 
 const (
-	LinearGradient GradientType = "linear"
-	RadialGradient GradientType = "radial"
+	LinearGradient  GradientType = "linear"
+	RadialGradient  GradientType = "radial"
+	ConicGradient   GradientType = "conic"
+	DiamondGradient GradientType = "diamond"
 )
 
+// The color space the gradient colors are blended in
+type GradientSpace string
+
+const (
+	// Blends the sRGB components directly, the same as CSS and most design tools do by default
+	GradientSpaceSrgb GradientSpace = "srgb"
+	// Blends the linear light values, the physically correct mix of the two lights
+	GradientSpaceLinearRgb GradientSpace = "linear-rgb"
+	// Perceptually uniform, avoids the gray dead zone of the sRGB blend without shifting the hue
+	GradientSpaceOklab GradientSpace = "oklab"
+	// The polar form of OKLab, blends the hue over the shorter arc
+	GradientSpaceOklch GradientSpace = "oklch"
+	// Same as [GradientSpaceOklch] but blends the hue over the longer arc
+	GradientSpaceOklchLonger GradientSpace = "oklch-longer"
+	// The polar form of CIE L*a*b*, blends the hue over the shorter arc. The default
+	GradientSpaceHcl GradientSpace = "hcl"
+	// Blends the hue over the shorter arc of the HSV color wheel
+	GradientSpaceHsvShorter GradientSpace = "hsv-shorter"
+	// Blends the hue over the longer arc of the HSV color wheel, e.g: red to blue will go through yellow and green
+	GradientSpaceHsvLonger GradientSpace = "hsv-longer"
+)
+
+var GradientSpaces = []GradientSpace{
+	GradientSpaceSrgb,
+	GradientSpaceLinearRgb,
+	GradientSpaceOklab,
+	GradientSpaceOklch,
+	GradientSpaceOklchLonger,
+	GradientSpaceHcl,
+	GradientSpaceHsvShorter,
+	GradientSpaceHsvLonger,
+}
+
+func (space GradientSpace) blend(c1, c2 colorful.Color, t float64) colorful.Color {
+	switch space {
+	case GradientSpaceSrgb:
+		return c1.BlendRgb(c2, t)
+
+	case GradientSpaceLinearRgb:
+		r1, g1, b1 := c1.LinearRgb()
+		r2, g2, b2 := c2.LinearRgb()
+		return colorful.LinearRgb(lerp(r1, r2, t), lerp(g1, g2, t), lerp(b1, b2, t))
+
+	case GradientSpaceOklab:
+		l1, a1, b1 := toOklab(c1)
+		l2, a2, b2 := toOklab(c2)
+		return fromOklab(lerp(l1, l2, t), lerp(a1, a2, t), lerp(b1, b2, t))
+
+	case GradientSpaceOklch, GradientSpaceOklchLonger:
+		l1, a1, b1 := toOklab(c1)
+		l2, a2, b2 := toOklab(c2)
+		c1Chroma, h1 := math.Hypot(a1, b1), math.Atan2(b1, a1)*180/math.Pi
+		c2Chroma, h2 := math.Hypot(a2, b2), math.Atan2(b2, a2)*180/math.Pi
+
+		// a gray has no hue, use the hue of the other color so we don't pass through a random one
+		const achromatic = 1e-4
+		if c1Chroma < achromatic {
+			h1 = h2
+		}
+		if c2Chroma < achromatic {
+			h2 = h1
+		}
+
+		h := interpolateHue(h1, h2, t, space == GradientSpaceOklchLonger) * math.Pi / 180
+		chroma := lerp(c1Chroma, c2Chroma, t)
+		return fromOklab(lerp(l1, l2, t), chroma*math.Cos(h), chroma*math.Sin(h))
+
+	case GradientSpaceHsvShorter, GradientSpaceHsvLonger:
+		h1, s1, v1 := c1.Hsv()
+		h2, s2, v2 := c2.Hsv()
+		h := interpolateHue(h1, h2, t, space == GradientSpaceHsvLonger)
+		return colorful.Hsv(h, lerp(s1, s2, t), lerp(v1, v2, t))
+
+	default:
+		return c1.BlendHcl(c2, t)
+	}
+}
+
+func lerp(a, b, t float64) float64 {
+	return a + (b-a)*t
+}
+
+// [h1], [h2] in degrees, the result is in [0..360)
+func interpolateHue(h1, h2, t float64, longer bool) float64 {
+	// the shorter signed distance from h1 to h2 in (-180..180]
+	delta := math.Mod(math.Mod(h2-h1, 360)+540, 360) - 180
+
+	if longer && delta != 0 {
+		if delta > 0 {
+			delta -= 360
+		} else {
+			delta += 360
+		}
+	}
+
+	return math.Mod(h1+t*delta+360, 360)
+}
+
+// From https://bottosson.github.io/posts/oklab/
+func toOklab(c colorful.Color) (l, a, b float64) {
+	r, g, bl := c.LinearRgb()
+
+	lms1 := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*bl)
+	lms2 := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*bl)
+	lms3 := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*bl)
+
+	l = 0.2104542553*lms1 + 0.7936177850*lms2 - 0.0040720468*lms3
+	a = 1.9779984951*lms1 - 2.4285922050*lms2 + 0.4505937099*lms3
+	b = 0.0259040371*lms1 + 0.7827717662*lms2 - 0.8086757660*lms3
+	return l, a, b
+}
+
+func fromOklab(l, a, b float64) colorful.Color {
+	lms1 := l + 0.3963377774*a + 0.2158037573*b
+	lms2 := l - 0.1055613458*a - 0.0638541728*b
+	lms3 := l - 0.0894841775*a - 1.2914855480*b
+
+	lms1 = lms1 * lms1 * lms1
+	lms2 = lms2 * lms2 * lms2
+	lms3 = lms3 * lms3 * lms3
+
+	return colorful.LinearRgb(
+		+4.0767416621*lms1-3.3077115913*lms2+0.2309699292*lms3,
+		-1.2684380046*lms1+2.6097574011*lms2-0.3413193965*lms3,
+		-0.0041960863*lms1-0.7034186147*lms2+1.7076147010*lms3,
+	)
+}
+
 type GradientTableItem struct {
 	Col colorful.Color
 	// The position keypoint has to live in the range [0,1]
 	Pos float64
+	// Between [0..1], 0 is fully opaque and 1 is fully transparent.
+	// It's inverted so the zero value of the item stays opaque
+	Transparency float64
+}
+
+func (item GradientTableItem) alpha() float64 {
+	return 1 - item.Transparency
 }
 
 // This table contains the "keypoints" of the color gradient you want to generate.
@@ -28,13 +165,43 @@ type GradientTable []GradientTableItem
 // the two colors around `t`.
 // Note: It relies heavily on the fact that the gradient keypoints are sorted.
 func (gt GradientTable) GetInterpolatedColorFor(t float64) colorful.Color {
+	return gt.GetInterpolatedColorInSpaceFor(t, GradientSpaceHcl)
+}
+
+// Same as [GradientTable.GetInterpolatedColorFor] but blends the two colors around `t` in the given color space.
+// Note: It relies heavily on the fact that the gradient keypoints are sorted.
+func (gt GradientTable) GetInterpolatedColorInSpaceFor(t float64, space GradientSpace) colorful.Color {
+	c, _ := gt.GetInterpolatedColorAndAlphaFor(t, space)
+	return c
+}
+
+// Same as [GradientTable.GetInterpolatedColorInSpaceFor] but also returns the interpolated alpha between [0..1].
+// The colors are blended as premultiplied by their alpha, so fading to a transparent stop will not pass through its color.
+// Note: It relies heavily on the fact that the gradient keypoints are sorted.
+func (gt GradientTable) GetInterpolatedColorAndAlphaFor(t float64, space GradientSpace) (colorful.Color, float64) {
 	for i := range len(gt) - 1 {
 		c1 := gt[i]
 		c2 := gt[i+1]
 		if c1.Pos <= t && t <= c2.Pos {
+			// a hard stop, as in css the color changes to c2 at once
+			if c2.Pos == c1.Pos {
+				return c2.Col, c2.alpha()
+			}
+
 			// We are in between c1 and c2. Go blend them!
 			t := (t - c1.Pos) / (c2.Pos - c1.Pos)
-			return c1.Col.BlendHcl(c2.Col, t).Clamped()
+
+			a1, a2 := c1.alpha(), c2.alpha()
+			alpha := lerp(a1, a2, t)
+
+			// blending the premultiplied colors then dividing by the alpha
+			// is the same as blending the straight colors with a weighted t
+			colorT := t
+			if alpha > 0 {
+				colorT = t * a2 / alpha
+			}
+
+			return space.blend(c1.Col, c2.Col, colorT).Clamped(), alpha
 		}
 	}
 
@@ -42,14 +209,15 @@ func (gt GradientTable) GetInterpolatedColorFor(t float64) colorful.Color {
 	//
 	// either we are before a key point, then use the first color
 	if t <= gt[0].Pos {
-		return gt[0].Col
+		return gt[0].Col, gt[0].alpha()
 	}
 	// or we're at (or past) the last gradient keypoint.
-	return gt[len(gt)-1].Col
+	last := gt[len(gt)-1]
+	return last.Col, last.alpha()
 }
 
-func createLinearGradient(colorsTable GradientTable, degree int, w, h int) image.Image {
-	img := image.NewRGBA(image.Rect(0, 0, w, h))
+func createLinearGradient(colorsTable GradientTable, space GradientSpace, degree int, w, h int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
 
 	theta := float64(degree) * math.Pi / 180 // to radian
 	ux, uy := math.Cos(theta), math.Sin(theta)
@@ -79,33 +247,141 @@ func createLinearGradient(colorsTable GradientTable, degree int, w, h int) image
 			// normalize to [0…1]
 			t := (r - rMin) / (rMax - rMin)
 
-			c := colorsTable.GetInterpolatedColorFor(t)
-			img.Set(x, y, c)
+			c, a := colorsTable.GetInterpolatedColorAndAlphaFor(t, space)
+			img.SetNRGBA(x, y, toNRGBA(c, a))
 		}
 	}
 
 	return img
 }
 
-func createRadialGradient(colorsTable GradientTable, w, h int) image.Image {
-	img := image.NewRGBA(image.Rect(0, 0, w, h))
-	center := image.Pt(w/2, h/2)
-	r := (math.Min(float64(w), float64(h))) / 2
+// A point relative to the image size
+type GradientPoint struct {
+	// between [0..1] as percentage of the width of the image
+	X float64
+	// between [0..1] as percentage of the height of the image
+	Y float64
+}
+
+type RadialGradientOptions struct {
+	// The center of the gradient ellipse. 0.5,0.5 is the center of the image
+	Center GradientPoint
+
+	// As percentage of the minimum axis (w,h) of the image. 0.5 will touch the edges of a square image.
+	// Zero falls back to 0.5
+	Radius float64
+
+	// The ratio between the horizontal and the vertical radius. 1 is a circle, 2 is an ellipse twice as wide as it is tall.
+	// Zero falls back to 1
+	Aspect float64
+
+	// The point where the gradient starts (the first stop). It should be inside the gradient ellipse.
+	// nil falls back to the center
+	Focal *GradientPoint
+}
+
+func DefaultRadialGradientOptions() RadialGradientOptions {
+	return RadialGradientOptions{
+		Center: GradientPoint{X: 0.5, Y: 0.5},
+		Radius: 0.5,
+		Aspect: 1,
+	}
+}
+
+func createRadialGradient(colorsTable GradientTable, space GradientSpace, option RadialGradientOptions, w, h int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
 
-	// distance between two points: √( (x1-x2)^2 + (y1-y2)^2 )
-	distance := func(p1, p2 image.Point) float64 {
-		return math.Sqrt(math.Pow(float64(p2.X-p1.X), 2) + math.Pow(float64(p2.Y-p1.Y), 2))
+	if option.Radius <= 0 {
+		option.Radius = 0.5
 	}
+	if option.Aspect <= 0 {
+		option.Aspect = 1
+	}
+	if option.Focal == nil {
+		option.Focal = &option.Center
+	}
+
+	W, H := float64(w), float64(h)
+	r := math.Min(W, H) * option.Radius
+
+	// we work in a space where the y axis is stretched by the aspect so the ellipse becomes a circle of radius r
+	cx, cy := W*option.Center.X, H*option.Center.Y*option.Aspect
+	fx, fy := W*option.Focal.X-cx, H*option.Focal.Y*option.Aspect-cy // relative to the center
+
+	// keep the focal point inside the circle, otherwise the gradient will not cover the image
+	if fd := math.Hypot(fx, fy); fd > r*0.99 {
+		fx *= r * 0.99 / fd
+		fy *= r * 0.99 / fd
+	}
+
+	for y := range h {
+		for x := range w {
+			// the ray from the focal point to (x,y)
+			dx := float64(x) - cx - fx
+			dy := float64(y)*option.Aspect - cy - fy
+
+			// find s where |f + s*d| = r, then (x,y) is at 1/s of the way between the focal point and the edge
+			a := dx*dx + dy*dy
+			var t float64
+			if a != 0 {
+				b := 2 * (fx*dx + fy*dy)
+				c := fx*fx + fy*fy - r*r
+				s := (-b + math.Sqrt(b*b-4*a*c)) / (2 * a)
+				t = 1 / s
+			}
+
+			c, a := colorsTable.GetInterpolatedColorAndAlphaFor(t, space)
+			img.SetNRGBA(x, y, toNRGBA(c, a))
+		}
+	}
+
+	return img
+}
+
+// [startDegree] the angle where the sweep starts, 0 points to the right and the sweep goes clockwise.
+// [cx], [cy] the center of the sweep in pixels.
+func createConicGradient(colorsTable GradientTable, space GradientSpace, startDegree int, cx, cy float64, w, h int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+
+	start := float64(startDegree) * math.Pi / 180 // to radian
+
+	for y := range h {
+		for x := range w {
+			// the angle of (x,y) around the center, the y axis points down so the sweep is clockwise
+			angle := math.Atan2(float64(y)-cy, float64(x)-cx) - start
+
+			// normalize to [0…2π)
+			angle = math.Mod(angle, 2*math.Pi)
+			if angle < 0 {
+				angle += 2 * math.Pi
+			}
+
+			// normalize to [0…1]
+			t := angle / (2 * math.Pi)
+
+			c, a := colorsTable.GetInterpolatedColorAndAlphaFor(t, space)
+			img.SetNRGBA(x, y, toNRGBA(c, a))
+		}
+	}
+
+	return img
+}
+
+// [cx], [cy] the center of the diamond in pixels.
+func createDiamondGradient(colorsTable GradientTable, space GradientSpace, cx, cy float64, w, h int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	r := (math.Min(float64(w), float64(h))) / 2
 
 	for y := range h {
 		for x := range w {
-			distance := math.Abs(distance(center, image.Pt(x, y)))
+			// manhattan distance, the points with the same distance forms a diamond
+			distance := math.Abs(float64(x)-cx) + math.Abs(float64(y)-cy)
 
 			// normalize to [0…1]
 			t := distance / r
 
-			c := colorsTable.GetInterpolatedColorFor(t)
-			img.Set(x, y, c)
+			c, a := colorsTable.GetInterpolatedColorAndAlphaFor(t, space)
+			img.SetNRGBA(x, y, toNRGBA(c, a))
 		}
 	}
 
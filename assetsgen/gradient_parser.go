@@ -0,0 +1,551 @@
+package assetsgen
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+var (
+	ErrInvalidGradientSpec     = errors.New("invalid gradient spec")
+	ErrGradientNeedsTwoStops   = errors.New("the gradient needs at least two color stops")
+	ErrGradientStopOutOfRange  = errors.New("the gradient stops should be between 0..1 (0%..100%)")
+	ErrGradientStopsNotSorted  = errors.New("the gradient stops should be in ascending order")
+	ErrUnsupportedGradientType = errors.New("unsupported gradient type. possible values (linear-gradient, radial-gradient, conic-gradient, diamond-gradient)")
+)
+
+// The parsed form of a css like gradient, see [ParseGradient]
+type GradientSpec struct {
+	Type  GradientType
+	Table GradientTable
+
+	// The degree of the linear gradient or the start angle of the conic gradient.
+	// Already converted from the css angles to the convention of [NewLinearGradientBackground] and [NewConicGradientBackground]
+	Degree int
+
+	// The center of the conic and diamond gradients
+	Center GradientPoint
+
+	Radial RadialGradientOptions
+
+	// Empty when the spec does not specify it
+	Space GradientSpace
+}
+
+func (spec GradientSpec) BackgroundIcon() BackgroundIcon {
+	var bgIcon BackgroundIcon
+
+	switch spec.Type {
+	case LinearGradient:
+		bgIcon = NewLinearGradientBackground(spec.Table, spec.Degree)
+	case RadialGradient:
+		bgIcon = NewRadialGradientBackground(spec.Table, spec.Radial)
+	case ConicGradient:
+		bgIcon = NewConicGradientBackground(spec.Table, spec.Degree, spec.Center.X, spec.Center.Y)
+	case DiamondGradient:
+		bgIcon = NewDiamondGradientBackground(spec.Table, spec.Center.X, spec.Center.Y)
+	}
+
+	if len(spec.Space) != 0 {
+		bgIcon = WithGradientSpace(bgIcon, spec.Space)
+	}
+
+	return bgIcon
+}
+
+// Parses a css like gradient e.g:
+//
+//	linear-gradient(90deg, #f00 0%, #00f 100%)
+//	linear-gradient(to bottom right, #f00, #ff0 30%, #00f)
+//	linear-gradient(in oklch 45deg, #f00, #00f)
+//	radial-gradient(circle closest-side at 30% 30%, #fff, #3040a0)
+//	radial-gradient(at top right, #fff, #3040a0)
+//	conic-gradient(from 90deg at 50% 50%, #f00, #00f, #f00)
+//	diamond-gradient(at 50% 50%, #fff, #000)
+//
+// The stops positions can be percentages or numbers between [0..1], the missing ones are filled in evenly.
+// The stops should be in ascending order. The colors are hex colors with optional alpha or the "transparent" keyword.
+// The interpolation space can be one of [GradientSpaces] in addition to the css names srgb-linear and (hsv|oklch) longer hue.
+func ParseGradient(spec string) (GradientSpec, error) {
+	spec = strings.TrimSpace(spec)
+
+	open := strings.IndexRune(spec, '(')
+	if open < 0 || !strings.HasSuffix(spec, ")") {
+		return GradientSpec{}, fmt.Errorf("%w: %q", ErrInvalidGradientSpec, spec)
+	}
+
+	out := GradientSpec{
+		Center: GradientPoint{X: 0.5, Y: 0.5},
+		Radial: DefaultRadialGradientOptions(),
+	}
+
+	switch strings.TrimSpace(strings.ToLower(spec[:open])) {
+	case "linear-gradient":
+		out.Type = LinearGradient
+		out.Degree = cssAngleToDegree(180) // css defaults to "to bottom"
+	case "radial-gradient":
+		out.Type = RadialGradient
+		out.Radial.Radius = radialSizeToRadius("farthest-corner", out.Radial.Center) // the css default
+	case "conic-gradient":
+		out.Type = ConicGradient
+		out.Degree = cssAngleToDegree(0) // css starts from the top
+	case "diamond-gradient":
+		out.Type = DiamondGradient
+	default:
+		return GradientSpec{}, ErrUnsupportedGradientType
+	}
+
+	args := splitTopLevel(spec[open+1 : len(spec)-1])
+	if len(args) == 0 {
+		return GradientSpec{}, ErrGradientNeedsTwoStops
+	}
+
+	// the first argument is either the configuration of the gradient or a stop, a typo in the color
+	// of the first stop should be reported as an invalid color, not as an invalid configuration
+	if isGradientConfig(args[0]) {
+		err := out.parseConfig(args[0])
+		if err != nil {
+			return GradientSpec{}, err
+		}
+		args = args[1:]
+	}
+
+	table := make(GradientTable, 0, len(args))
+	for _, arg := range args {
+		items, err := parseGradientStop(arg)
+		if err != nil {
+			return GradientSpec{}, err
+		}
+		table = append(table, items...)
+	}
+
+	table = table.FillMissingPositions()
+	err := table.Validate()
+	if err != nil {
+		return GradientSpec{}, err
+	}
+	out.Table = table
+
+	return out, nil
+}
+
+// The css angles starts from the top and goes clockwise, ours starts from the right
+func cssAngleToDegree(cssDegree float64) int {
+	return int(math.Round(cssDegree)) - 90
+}
+
+// The configuration starts with a keyword (to, at, in, from, the shapes and the radial sizes), an angle or a radial size
+func isGradientConfig(arg string) bool {
+	fields := strings.Fields(strings.ToLower(arg))
+	if len(fields) == 0 {
+		return false
+	}
+
+	first := fields[0]
+	switch {
+	case slices.Contains([]string{"to", "at", "in", "from", "circle", "ellipse"}, first):
+		return true
+	case strings.HasPrefix(first, "closest-") || strings.HasPrefix(first, "farthest-") || strings.HasSuffix(first, "%"):
+		return true
+	}
+	_, err := parseAngle(first)
+	return err == nil
+}
+
+func (out *GradientSpec) parseConfig(config string) error {
+	fields := strings.Fields(strings.ToLower(config))
+	radialSize := "farthest-corner" // the css default
+
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+
+		switch {
+		case field == "in":
+			space, n, err := parseGradientSpace(fields[i+1:])
+			if err != nil {
+				return err
+			}
+			out.Space = space
+			i += n
+
+		case field == "to" && out.Type == LinearGradient:
+			degree, n, err := parseSideOrCorner(fields[i+1:])
+			if err != nil {
+				return err
+			}
+			out.Degree = cssAngleToDegree(degree)
+			i += n
+
+		case field == "from" && out.Type == ConicGradient:
+			if i+1 >= len(fields) {
+				return fmt.Errorf("%w: missing angle after from", ErrInvalidGradientSpec)
+			}
+			degree, err := parseAngle(fields[i+1])
+			if err != nil {
+				return err
+			}
+			out.Degree = cssAngleToDegree(degree)
+			i++
+
+		case field == "at" && out.Type != LinearGradient:
+			center, n, err := parseGradientCenter(fields[i+1:])
+			if err != nil {
+				return err
+			}
+			out.Center = center
+			out.Radial.Center = out.Center
+			i += n
+
+		case (field == "circle" || field == "ellipse") && out.Type == RadialGradient:
+			// the icons are squares so both are the same
+
+		case strings.HasPrefix(field, "closest-") || strings.HasPrefix(field, "farthest-") || strings.HasSuffix(field, "%"):
+			if out.Type != RadialGradient {
+				return fmt.Errorf("%w: unexpected %q", ErrInvalidGradientSpec, field)
+			}
+			// resolved after the center is known
+			radialSize = field
+
+		case out.Type == LinearGradient:
+			degree, err := parseAngle(field)
+			if err != nil {
+				return err
+			}
+			out.Degree = cssAngleToDegree(degree)
+
+		default:
+			return fmt.Errorf("%w: unexpected %q", ErrInvalidGradientSpec, field)
+		}
+	}
+
+	out.Radial.Radius = radialSizeToRadius(radialSize, out.Radial.Center)
+
+	return nil
+}
+
+// The radius as percentage of the minimum axis (w,h) of a square image
+func radialSizeToRadius(size string, center GradientPoint) float64 {
+	closestX, closestY := math.Min(center.X, 1-center.X), math.Min(center.Y, 1-center.Y)
+	farthestX, farthestY := math.Max(center.X, 1-center.X), math.Max(center.Y, 1-center.Y)
+
+	switch size {
+	case "closest-side":
+		return math.Min(closestX, closestY)
+	case "farthest-side":
+		return math.Max(farthestX, farthestY)
+	case "closest-corner":
+		return math.Hypot(closestX, closestY)
+	case "farthest-corner":
+		return math.Hypot(farthestX, farthestY)
+	default:
+		p, err := parsePosition(size)
+		if err != nil || p <= 0 {
+			return DefaultRadialGradientOptions().Radius
+		}
+		return p
+	}
+}
+
+func parseGradientSpace(fields []string) (GradientSpace, int, error) {
+	if len(fields) == 0 {
+		return "", 0, fmt.Errorf("%w: missing color space after in", ErrInvalidGradientSpec)
+	}
+
+	longer := len(fields) >= 3 && fields[1] == "longer" && fields[2] == "hue"
+	shorter := len(fields) >= 3 && fields[1] == "shorter" && fields[2] == "hue"
+	consumed := 1
+	if longer || shorter {
+		consumed = 3
+	}
+
+	switch fields[0] {
+	case "srgb":
+		return GradientSpaceSrgb, consumed, nil
+	case "srgb-linear", string(GradientSpaceLinearRgb):
+		return GradientSpaceLinearRgb, consumed, nil
+	case string(GradientSpaceOklab):
+		return GradientSpaceOklab, consumed, nil
+	case string(GradientSpaceOklch):
+		if longer {
+			return GradientSpaceOklchLonger, consumed, nil
+		}
+		return GradientSpaceOklch, consumed, nil
+	case string(GradientSpaceOklchLonger):
+		return GradientSpaceOklchLonger, consumed, nil
+	case string(GradientSpaceHcl), "lch":
+		return GradientSpaceHcl, consumed, nil
+	case "hsv", string(GradientSpaceHsvShorter):
+		if longer {
+			return GradientSpaceHsvLonger, consumed, nil
+		}
+		return GradientSpaceHsvShorter, consumed, nil
+	case string(GradientSpaceHsvLonger):
+		return GradientSpaceHsvLonger, consumed, nil
+	}
+
+	return "", 0, fmt.Errorf("%w: unsupported color space %q", ErrInvalidGradientSpec, fields[0])
+}
+
+// Returns the css angle of the side or corner e.g: "to right" is 90deg
+func parseSideOrCorner(fields []string) (float64, int, error) {
+	var x, y float64
+	consumed := 0
+	for _, field := range fields[:min(2, len(fields))] {
+		switch field {
+		case "top":
+			y = -1
+		case "bottom":
+			y = 1
+		case "left":
+			x = -1
+		case "right":
+			x = 1
+		default:
+			return 0, 0, fmt.Errorf("%w: expected a side or corner after to, got %q", ErrInvalidGradientSpec, field)
+		}
+		consumed++
+	}
+
+	if consumed == 0 {
+		return 0, 0, fmt.Errorf("%w: expected a side or corner after to", ErrInvalidGradientSpec)
+	}
+
+	return math.Mod(math.Atan2(x, -y)*180/math.Pi+360, 360), consumed, nil
+}
+
+// Returns the angle in degrees
+func parseAngle(s string) (float64, error) {
+	units := []struct {
+		suffix string
+		factor float64
+	}{
+		{"deg", 1},
+		{"grad", 360.0 / 400},
+		{"rad", 180 / math.Pi},
+		{"turn", 360},
+	}
+
+	for _, unit := range units {
+		if v, ok := strings.CutSuffix(s, unit.suffix); ok {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				break
+			}
+			return f * unit.factor, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: invalid angle %q", ErrInvalidGradientSpec, s)
+}
+
+type positionAxis int
+
+const (
+	positionAxisAny positionAxis = iota
+	positionAxisX
+	positionAxisY
+)
+
+// One value of a css position
+type centerValue struct {
+	pos     float64
+	axis    positionAxis
+	keyword bool
+}
+
+var centerKeywords = map[string]centerValue{
+	"center": {pos: 0.5, axis: positionAxisAny, keyword: true},
+	"left":   {pos: 0, axis: positionAxisX, keyword: true},
+	"right":  {pos: 1, axis: positionAxisX, keyword: true},
+	"top":    {pos: 0, axis: positionAxisY, keyword: true},
+	"bottom": {pos: 1, axis: positionAxisY, keyword: true},
+}
+
+func parseCenterValue(s string) (centerValue, error) {
+	if v, ok := centerKeywords[s]; ok {
+		return v, nil
+	}
+	pos, err := parsePosition(s)
+	return centerValue{pos: pos}, err
+}
+
+// The css position after "at": one or two values, each a keyword (center, left, right, top and bottom) or a position.
+// e.g: "30% 70%", "top", "right 20%", "top left". A single value keeps the other axis at the center
+func parseGradientCenter(fields []string) (GradientPoint, int, error) {
+	if len(fields) == 0 {
+		return GradientPoint{}, 0, fmt.Errorf("%w: missing position after at", ErrInvalidGradientSpec)
+	}
+
+	x, err := parseCenterValue(fields[0])
+	if err != nil {
+		return GradientPoint{}, 0, err
+	}
+
+	var y centerValue
+	if len(fields) > 1 {
+		y, err = parseCenterValue(fields[1])
+	}
+	if len(fields) == 1 || err != nil {
+		if x.axis == positionAxisY {
+			return GradientPoint{X: 0.5, Y: x.pos}, 1, nil
+		}
+		return GradientPoint{X: x.pos, Y: 0.5}, 1, nil
+	}
+
+	// only two keywords can come in any order, e.g: "top left" but not "top 20%"
+	if (x.axis == positionAxisY || y.axis == positionAxisX) && x.keyword && y.keyword {
+		x, y = y, x
+	}
+	if x.axis == positionAxisY || y.axis == positionAxisX {
+		return GradientPoint{}, 0, fmt.Errorf("%w: invalid position %q", ErrInvalidGradientSpec, fields[0]+" "+fields[1])
+	}
+
+	return GradientPoint{X: x.pos, Y: y.pos}, 2, nil
+}
+
+// Parses a percentage or a number between [0..1]
+func parsePosition(s string) (float64, error) {
+	if v, ok := strings.CutSuffix(s, "%"); ok {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: invalid position %q", ErrInvalidGradientSpec, s)
+		}
+		return f / 100, nil
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid position %q", ErrInvalidGradientSpec, s)
+	}
+	return f, nil
+}
+
+// A stop is a color with zero, one or two positions, e.g: "#f00", "#f00 10%", "#f00 10% 20%"
+func parseGradientStop(stop string) ([]GradientTableItem, error) {
+	fields := strings.Fields(stop)
+	if len(fields) == 0 || len(fields) > 3 {
+		return nil, fmt.Errorf("%w: invalid color stop %q", ErrInvalidGradientSpec, stop)
+	}
+
+	c, alpha, err := parseGradientColor(fields[0])
+	if err != nil {
+		return nil, err
+	}
+
+	item := GradientTableItem{Col: c, Pos: math.NaN(), Transparency: 1 - alpha}
+	if len(fields) == 1 {
+		return []GradientTableItem{item}, nil
+	}
+
+	items := make([]GradientTableItem, 0, 2)
+	for _, field := range fields[1:] {
+		item.Pos, err = parsePosition(field)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func parseGradientColor(s string) (colorful.Color, float64, error) {
+	if strings.EqualFold(s, "transparent") {
+		return colorful.Color{}, 0, nil
+	}
+	return ParseHexColor(s)
+}
+
+// Splits on the commas that are not inside parentheses
+func splitTopLevel(s string) []string {
+	var out []string
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				out = append(out, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); len(last) != 0 || len(out) != 0 {
+		out = append(out, last)
+	}
+	return out
+}
+
+// Returns a copy of the table where the NaN positions are filled in.
+// The first and last stops default to 0 and 1, the others are spread evenly between their neighbours with known positions
+func (gt GradientTable) FillMissingPositions() GradientTable {
+	out := slices.Clone(gt)
+	if len(out) == 0 {
+		return out
+	}
+
+	if math.IsNaN(out[0].Pos) {
+		out[0].Pos = 0
+	}
+	if math.IsNaN(out[len(out)-1].Pos) {
+		out[len(out)-1].Pos = 1
+	}
+
+	prev := 0
+	for i := 1; i < len(out); i++ {
+		if math.IsNaN(out[i].Pos) {
+			continue
+		}
+
+		// spread the missing stops between prev and i evenly
+		step := (out[i].Pos - out[prev].Pos) / float64(i-prev)
+		for j := prev + 1; j < i; j++ {
+			out[j].Pos = out[prev].Pos + step*float64(j-prev)
+		}
+		prev = i
+	}
+
+	return out
+}
+
+// Checks that the table has at least two stops, every position is between [0..1] and they are in ascending order.
+// Use [GradientTable.Sorted] to sort the stops instead of failing
+func (gt GradientTable) Validate() error {
+	if len(gt) < 2 {
+		return ErrGradientNeedsTwoStops
+	}
+
+	for i, item := range gt {
+		if math.IsNaN(item.Pos) || item.Pos < 0 || item.Pos > 1 {
+			return fmt.Errorf("%w: got %v", ErrGradientStopOutOfRange, item.Pos)
+		}
+		if i > 0 && item.Pos < gt[i-1].Pos {
+			return fmt.Errorf("%w: %v comes after %v", ErrGradientStopsNotSorted, item.Pos, gt[i-1].Pos)
+		}
+	}
+
+	return nil
+}
+
+// Returns a copy of the table sorted by the stops positions, the stops with the same position keep their order
+func (gt GradientTable) Sorted() GradientTable {
+	out := slices.Clone(gt)
+	slices.SortStableFunc(out, func(a, b GradientTableItem) int {
+		switch {
+		case a.Pos < b.Pos:
+			return -1
+		case a.Pos > b.Pos:
+			return 1
+		}
+		return 0
+	})
+	return out
+}
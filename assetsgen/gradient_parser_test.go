@@ -0,0 +1,276 @@
+package assetsgen
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+func TestParseGradient(t *testing.T) {
+	tests := []struct {
+		spec       string
+		wantType   GradientType
+		wantDegree int
+		wantCenter GradientPoint
+		// 0 to skip
+		wantRadius float64
+		wantSpace  GradientSpace
+		wantStops  []float64
+		wantErr    error
+	}{
+		{
+			spec:       "linear-gradient(90deg, #f00 0%, #00f 100%)",
+			wantType:   LinearGradient,
+			wantDegree: 0,
+			wantCenter: GradientPoint{X: 0.5, Y: 0.5},
+			wantStops:  []float64{0, 1},
+		},
+		{
+			spec:       "linear-gradient(#f00, #ff0, #00f)",
+			wantType:   LinearGradient,
+			wantDegree: 90,
+			wantCenter: GradientPoint{X: 0.5, Y: 0.5},
+			wantStops:  []float64{0, 0.5, 1},
+		},
+		{
+			spec:       "linear-gradient(to right, #f00, #00f 80%)",
+			wantType:   LinearGradient,
+			wantDegree: 0,
+			wantCenter: GradientPoint{X: 0.5, Y: 0.5},
+			wantStops:  []float64{0, 0.8},
+		},
+		{
+			spec:       "linear-gradient(in oklch 45deg, #f00, #00f)",
+			wantType:   LinearGradient,
+			wantDegree: -45,
+			wantCenter: GradientPoint{X: 0.5, Y: 0.5},
+			wantSpace:  GradientSpaceOklch,
+			wantStops:  []float64{0, 1},
+		},
+		{
+			spec:       "radial-gradient(circle closest-side at 30% 30%, #fff, #3040a0)",
+			wantType:   RadialGradient,
+			wantCenter: GradientPoint{X: 0.3, Y: 0.3},
+			wantRadius: 0.3,
+			wantStops:  []float64{0, 1},
+		},
+		{
+			spec:       "radial-gradient(at top right, #fff, #3040a0)",
+			wantType:   RadialGradient,
+			wantCenter: GradientPoint{X: 1, Y: 0},
+			wantRadius: math.Sqrt2,
+			wantStops:  []float64{0, 1},
+		},
+		{
+			spec:       "radial-gradient(at right top, #fff, #3040a0)",
+			wantType:   RadialGradient,
+			wantCenter: GradientPoint{X: 1, Y: 0},
+			wantStops:  []float64{0, 1},
+		},
+		{
+			spec:       "radial-gradient(closest-side at left, #fff, #3040a0)",
+			wantType:   RadialGradient,
+			wantCenter: GradientPoint{X: 0, Y: 0.5},
+			wantStops:  []float64{0, 1},
+		},
+		{
+			spec:       "radial-gradient(at top, #fff, #3040a0)",
+			wantType:   RadialGradient,
+			wantCenter: GradientPoint{X: 0.5, Y: 0},
+			wantStops:  []float64{0, 1},
+		},
+		{
+			spec:       "conic-gradient(from 90deg at center, #f00, #00f, #f00)",
+			wantType:   ConicGradient,
+			wantDegree: 0,
+			wantCenter: GradientPoint{X: 0.5, Y: 0.5},
+			wantStops:  []float64{0, 0.5, 1},
+		},
+		{
+			spec:       "conic-gradient(at right 20%, #f00, #00f)",
+			wantType:   ConicGradient,
+			wantDegree: -90,
+			wantCenter: GradientPoint{X: 1, Y: 0.2},
+			wantStops:  []float64{0, 1},
+		},
+		{
+			spec:       "diamond-gradient(at 25% bottom, #fff, transparent)",
+			wantType:   DiamondGradient,
+			wantCenter: GradientPoint{X: 0.25, Y: 1},
+			wantStops:  []float64{0, 1},
+		},
+		{
+			spec:       "diamond-gradient(at center top, #fff, #000)",
+			wantType:   DiamondGradient,
+			wantCenter: GradientPoint{X: 0.5, Y: 0},
+			wantStops:  []float64{0, 1},
+		},
+		{
+			spec:       "linear-gradient(90deg, #ff0000 50%, #0000ff 50%)",
+			wantType:   LinearGradient,
+			wantDegree: 0,
+			wantCenter: GradientPoint{X: 0.5, Y: 0.5},
+			wantStops:  []float64{0.5, 0.5},
+		},
+		{spec: "radial-gradient(at top 20%, #fff, #000)", wantErr: ErrInvalidGradientSpec},
+		{spec: "radial-gradient(at left right, #fff, #000)", wantErr: ErrInvalidGradientSpec},
+		{spec: "radial-gradient(at, #fff, #000)", wantErr: ErrInvalidGradientSpec},
+		{spec: "radial-gradient(at middle, #fff, #000)", wantErr: ErrInvalidGradientSpec},
+		{spec: "linear-gradient(#f00", wantErr: ErrInvalidGradientSpec},
+		{spec: "linear-gradient(#ff00zz 0%, #00f)", wantErr: ErrInvalidHexColor},
+		{spec: "linear-gradient(red, #00f)", wantErr: ErrInvalidHexColor},
+		{spec: "linear-gradient(to middle, #f00, #00f)", wantErr: ErrInvalidGradientSpec},
+		{spec: "linear-gradient(#f00)", wantErr: ErrGradientNeedsTwoStops},
+		{spec: "linear-gradient(#f00 60%, #00f 40%)", wantErr: ErrGradientStopsNotSorted},
+		{spec: "linear-gradient(#f00 0%, #00f 150%)", wantErr: ErrGradientStopOutOfRange},
+		{spec: "sweep-gradient(#f00, #00f)", wantErr: ErrUnsupportedGradientType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseGradient(tt.spec)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ParseGradient() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseGradient() error = %v", err)
+			}
+
+			if got.Type != tt.wantType {
+				t.Errorf("Type = %v, want %v", got.Type, tt.wantType)
+			}
+			if got.Type != RadialGradient && got.Type != DiamondGradient && got.Degree != tt.wantDegree {
+				t.Errorf("Degree = %v, want %v", got.Degree, tt.wantDegree)
+			}
+			if !nearlyEqual(got.Center.X, tt.wantCenter.X) || !nearlyEqual(got.Center.Y, tt.wantCenter.Y) {
+				t.Errorf("Center = %v, want %v", got.Center, tt.wantCenter)
+			}
+			if got.Type == RadialGradient && got.Radial.Center != got.Center {
+				t.Errorf("Radial.Center = %v, want %v", got.Radial.Center, got.Center)
+			}
+			if tt.wantRadius != 0 && !nearlyEqual(got.Radial.Radius, tt.wantRadius) {
+				t.Errorf("Radial.Radius = %v, want %v", got.Radial.Radius, tt.wantRadius)
+			}
+			if got.Space != tt.wantSpace {
+				t.Errorf("Space = %v, want %v", got.Space, tt.wantSpace)
+			}
+			assertStops(t, got.Table, tt.wantStops)
+		})
+	}
+}
+
+func TestGradientHardStop(t *testing.T) {
+	spec, err := ParseGradient("linear-gradient(90deg, #ff0000 50%, #0000ff 50%)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	red, blue := colorful.Color{R: 1}, colorful.Color{B: 1}
+	tests := []struct {
+		t    float64
+		want colorful.Color
+	}{
+		{t: 0.25, want: red},
+		{t: 0.5, want: blue},
+		{t: 0.75, want: blue},
+	}
+
+	for _, space := range []GradientSpace{GradientSpaceSrgb, GradientSpaceOklch} {
+		for _, tt := range tests {
+			got, alpha := spec.Table.GetInterpolatedColorAndAlphaFor(tt.t, space)
+			if !got.AlmostEqualRgb(tt.want) || alpha != 1 {
+				t.Errorf("%s at %v = %v %v, want %v 1", space, tt.t, got, alpha, tt.want)
+			}
+		}
+	}
+}
+
+func TestGradientTableFillMissingPositions(t *testing.T) {
+	nan := math.NaN()
+
+	tests := []struct {
+		name string
+		pos  []float64
+		want []float64
+	}{
+		{name: "empty", pos: []float64{}, want: []float64{}},
+		{name: "all missing", pos: []float64{nan, nan, nan}, want: []float64{0, 0.5, 1}},
+		{name: "none missing", pos: []float64{0.1, 0.7}, want: []float64{0.1, 0.7}},
+		{name: "between two positions", pos: []float64{0.2, nan, nan, 0.8}, want: []float64{0.2, 0.4, 0.6, 0.8}},
+		{name: "missing ends", pos: []float64{nan, 0.5, nan}, want: []float64{0, 0.5, 1}},
+		{name: "single", pos: []float64{nan}, want: []float64{0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := tableWithPositions(tt.pos)
+			got := table.FillMissingPositions()
+			assertStops(t, got, tt.want)
+
+			// the table itself is not changed
+			for i, item := range table {
+				if !(math.IsNaN(item.Pos) && math.IsNaN(tt.pos[i])) && item.Pos != tt.pos[i] {
+					t.Errorf("the table was changed at %d: %v", i, item.Pos)
+				}
+			}
+		})
+	}
+}
+
+func TestGradientTableValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		pos     []float64
+		wantErr error
+	}{
+		{name: "valid", pos: []float64{0, 0.5, 1}},
+		{name: "same positions", pos: []float64{0, 0.5, 0.5, 1}},
+		{name: "one stop", pos: []float64{0}, wantErr: ErrGradientNeedsTwoStops},
+		{name: "no stops", pos: []float64{}, wantErr: ErrGradientNeedsTwoStops},
+		{name: "below 0", pos: []float64{-0.1, 1}, wantErr: ErrGradientStopOutOfRange},
+		{name: "above 1", pos: []float64{0, 1.1}, wantErr: ErrGradientStopOutOfRange},
+		{name: "missing position", pos: []float64{0, math.NaN()}, wantErr: ErrGradientStopOutOfRange},
+		{name: "not sorted", pos: []float64{0, 0.6, 0.4}, wantErr: ErrGradientStopsNotSorted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tableWithPositions(tt.pos).Validate()
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("Validate() = %v, want nil", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func tableWithPositions(pos []float64) GradientTable {
+	table := make(GradientTable, len(pos))
+	for i, p := range pos {
+		table[i] = GradientTableItem{Pos: p}
+	}
+	return table
+}
+
+func assertStops(t *testing.T, table GradientTable, want []float64) {
+	t.Helper()
+	if len(table) != len(want) {
+		t.Fatalf("got %d stops, want %d", len(table), len(want))
+	}
+	for i, item := range table {
+		if !nearlyEqual(item.Pos, want[i]) {
+			t.Errorf("stop %d is at %v, want %v", i, item.Pos, want[i])
+		}
+	}
+}
+
+func nearlyEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
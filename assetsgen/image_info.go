@@ -1,19 +1,16 @@
 package assetsgen
 
 import (
+	"errors"
 	"fmt"
 	"image"
 	"image/color"
-	"image/draw"
+	"io"
 	"math"
-	"os"
+	"path"
 	"path/filepath"
-	"slices"
-	"strings"
-	"sync"
 
 	"github.com/anthonynsimon/bild/adjust"
-	"github.com/anthonynsimon/bild/clone"
 	"github.com/anthonynsimon/bild/imgio"
 	"github.com/anthonynsimon/bild/transform"
 )
@@ -27,7 +24,9 @@ type imageInfo struct {
 	imageExt          string
 	encoder           imgio.Encoder
 	asset             asset
-	rootDir           *os.Root
+	output            OutputSink
+	ownedOutput       io.Closer
+	resizeOptions     ResizeOptions
 }
 
 func (ii *imageInfo) IsValid() bool {
@@ -43,12 +42,28 @@ func (s *imageInfoSlice) ForEach(fn func(imageInfo) imageInfo) *imageInfoSlice {
 	return s
 }
 
+func (s imageInfoSlice) resizeOptions() ResizeOptions {
+	if len(s) == 0 {
+		return DefaultResizeOptions()
+	}
+	return s[0].resizeOptions
+}
+
+// Resizes every image to the size of its asset, see [planResizes]
 func (s *imageInfoSlice) ResizeForAssets() *imageInfoSlice {
-	return s.ForEach(
-		func(imgInfo imageInfo) imageInfo {
-			return *imgInfo.ResizeForAsset()
-		},
-	)
+	images := make([]image.Image, len(*s))
+	sizes := make([]image.Point, len(*s))
+	for i, v := range *s {
+		imgBounds := v.img.Bounds()
+		w, h := v.asset.CalcSize(imgBounds.Dx(), imgBounds.Dy())
+		images[i] = v.img
+		sizes[i] = image.Pt(w, h)
+	}
+
+	for i, img := range planResizes(images, sizes, s.resizeOptions()) {
+		(*s)[i].img = img
+	}
+	return s
 }
 
 func (s *imageInfoSlice) CenterCanvasForAssets() *imageInfoSlice {
@@ -59,18 +74,18 @@ func (s *imageInfoSlice) CenterCanvasForAssets() *imageInfoSlice {
 	)
 }
 
+// Saves every image even if some of them fail, the errors are joined
 func (s imageInfoSlice) Save() error {
-	for _, v := range s {
-		v.Save()
-	}
-	return nil
+	return s.SaveWithCustomName("")
 }
 
+// Saves every image even if some of them fail, the errors are joined
 func (s imageInfoSlice) SaveWithCustomName(customImageName string) error {
+	var errs []error
 	for _, v := range s {
-		v.SaveWithCustomName(customImageName)
+		errs = append(errs, v.SaveWithCustomName(customImageName))
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 func (s *imageInfoSlice) SetAssets(assets []asset) *imageInfoSlice {
@@ -81,55 +96,42 @@ func (s *imageInfoSlice) SetAssets(assets []asset) *imageInfoSlice {
 	return s
 }
 
-func newImageInfo(imagePath string, savePath string) (*imageInfo, error) {
-	if err := IsFileExistsAndImage(imagePath); err != nil {
-		return &imageInfo{}, err
-	}
-
-	img, err := imgio.Open(imagePath)
-	if err != nil {
-		return &imageInfo{}, err
-	}
-
-	enc, err := imageEncoderFromPath(imagePath)
-	if err != nil {
-		return &imageInfo{}, err
-	}
-
-	imgName := filepath.Base(imagePath)
-	imageExt := filepath.Ext(imagePath)
-	imgNameWithoutExt := strings.ReplaceAll(imgName, imageExt, "")
-
-	rootDir, err := GetRootDir()
-	if err != nil {
-		return &imageInfo{}, err
-	}
-
-	subDirs := splitPath(savePath)
-	saveDirPath := ""
-	for _, subdir := range subDirs {
-
-		saveDirPath = filepath.Join(saveDirPath, subdir)
-		err = rootDir.Mkdir(saveDirPath, os.ModePerm)
-		if err != nil && !os.IsExist(err) {
+// [img] the starting image, usually one of the preprocessed images of the source. It will not be modified
+// [output] nil falls back to [RootFolderName] in the working directory, close it with [imageInfo.CloseOutput].
+// [savePath] slash separated
+func newImageInfoFromSource(src *SourceImage, img image.Image, output OutputSink, savePath string) (*imageInfo, error) {
+	var ownedOutput io.Closer
+	if output == nil {
+		dirSink, err := NewDirSink("")
+		if err != nil {
 			return &imageInfo{}, err
 		}
+		output, ownedOutput = dirSink, dirSink
 	}
 
 	imgInfo := &imageInfo{
 		img:               img,
-		encoder:           enc,
-		imagePath:         imagePath,
-		rootDir:           rootDir,
-		imageName:         imgName,
-		imageExt:          imageExt,
-		saveDirPath:       saveDirPath,
-		imgNameWithoutExt: imgNameWithoutExt,
+		encoder:           src.encoder,
+		imagePath:         src.imagePath,
+		output:            output,
+		ownedOutput:       ownedOutput,
+		imageName:         src.imageName,
+		imageExt:          src.imageExt,
+		saveDirPath:       path.Clean(savePath),
+		imgNameWithoutExt: src.imgNameWithoutExt,
 	}
 
 	return imgInfo, nil
 }
 
+// Closes the output if it was opened by [newImageInfoFromSource], the sinks passed in the options are left open
+func (imgInfo *imageInfo) CloseOutput() error {
+	if imgInfo.ownedOutput == nil {
+		return nil
+	}
+	return imgInfo.ownedOutput.Close()
+}
+
 func imageEncoderFromPath(imagePath string) (imgio.Encoder, error) {
 	ext := filepath.Ext(imagePath)
 	switch ext {
@@ -153,8 +155,40 @@ func (imgInfo *imageInfo) ResizeSquareUseingResampleFilter(x int, resampleFilter
 	return imgInfo.ResizeUseingResampleFilter(x, x, resampleFilter)
 }
 
+// Resizes using the filter and the color space of [imageInfo.SetResizeOptions], lanczos in sRGB by default
 func (imgInfo *imageInfo) Resize(w, h int) *imageInfo {
-	return imgInfo.ResizeUseingResampleFilter(w, h, transform.Lanczos)
+	imgBounds := imgInfo.img.Bounds()
+	if imgBounds.Dx() == w && imgBounds.Dy() == h { // it's already a resized to w,h
+		return imgInfo
+	}
+
+	imgInfo.img = imgInfo.resizeOptions.resize(imgInfo.img, w, h)
+	return imgInfo
+}
+
+// Saves as PNG if the source format can't hold the transparency, e.g: after keying out the background of a JPG
+func (imgInfo *imageInfo) EncodeWithAlpha() *imageInfo {
+	if imgInfo.imageExt == ".png" {
+		return imgInfo
+	}
+
+	imgInfo.encoder = imgio.PNGEncoder()
+	imgInfo.imageExt = ".png"
+	imgInfo.imageName = fmt.Sprint(imgInfo.imgNameWithoutExt, imgInfo.imageExt)
+	return imgInfo
+}
+
+// Saves as 32-bit PNG (8 bits RGBA) whatever the source format is and even if the image is opaque, see [encodePNG32]
+func (imgInfo *imageInfo) EncodeAsPNG32() *imageInfo {
+	imgInfo.encoder = encodePNG32
+	imgInfo.imageExt = ".png"
+	imgInfo.imageName = fmt.Sprint(imgInfo.imgNameWithoutExt, imgInfo.imageExt)
+	return imgInfo
+}
+
+func (imgInfo *imageInfo) SetResizeOptions(options ResizeOptions) *imageInfo {
+	imgInfo.resizeOptions = options
+	return imgInfo
 }
 
 func (imgInfo *imageInfo) ResizeUseingResampleFilter(w, h int, resampleFilter transform.ResampleFilter) *imageInfo {
@@ -169,23 +203,36 @@ func (imgInfo *imageInfo) ResizeUseingResampleFilter(w, h int, resampleFilter tr
 	return imgInfo
 }
 
+// Centers the image in a square transparent canvas, then adds [pading] on all the sides
 func (imgInfo *imageInfo) SquareImageWithEmptyPixels(pading int) *imageInfo {
+	return imgInfo.SquareImageWithOpticalCenter(pading, 0)
+}
+
+// Same as [imageInfo.SquareImageWithEmptyPixels] but moves the logo toward its visual center.
+// [amount] between [0..1] blends the bounding box center (0) with the alpha weighted centroid (1),
+// e.g: a play button triangle looks centered around 0.5
+func (imgInfo *imageInfo) SquareImageWithOpticalCenter(pading int, amount float64) *imageInfo {
 	imgBounds := imgInfo.img.Bounds()
-	w := imgBounds.Dx()
-	h := imgBounds.Dy()
+	w := float64(imgBounds.Dx())
+	h := float64(imgBounds.Dy())
 
-	if w == h { // it's already a square
-		return imgInfo
+	// the point of the image that lands on the center of the canvas
+	px, py := w/2, h/2
+	if amount > 0 {
+		dx, dy := opticalCenterOffset(asNRGBA(imgInfo.img))
+		px += dx * amount
+		py += dy * amount
 	}
 
-	var padX, padY int
-	if w < h {
-		padX = (h - w) / 2
-	} else {
-		padY = (w - h) / 2
+	// big enough to hold the image on both sides of that point
+	side := math.Ceil(max(w, h, 2*px, 2*(w-px), 2*py, 2*(h-py)))
+	if side == w && side == h && pading == 0 { // it's already a square
+		return imgInfo
 	}
 
-	imgInfo.img = clone.Pad(imgInfo.img, padX+pading, padY+pading, clone.NoFill)
+	size := int(side) + pading*2
+	at := image.Pt(int(math.Round(float64(size)/2-px)), int(math.Round(float64(size)/2-py)))
+	imgInfo.img = placeNRGBA(imgInfo.img, size, size, at)
 	return imgInfo
 }
 
@@ -194,7 +241,8 @@ func (imgInfo *imageInfo) Padding(padding int) *imageInfo {
 		return imgInfo
 	}
 
-	imgInfo.img = clone.Pad(imgInfo.img, padding, padding, clone.NoFill)
+	imgBounds := imgInfo.img.Bounds()
+	imgInfo.img = placeNRGBA(imgInfo.img, imgBounds.Dx()+padding*2, imgBounds.Dy()+padding*2, image.Pt(padding, padding))
 	return imgInfo
 }
 
@@ -219,23 +267,6 @@ func (imgInfo *imageInfo) ConvertNoneOpaqueToColor(newColor color.Color) *imageI
 	})
 }
 
-func (imgInfo *imageInfo) UpdatePixels(updater func(x, y int, c color.Color) color.Color) *imageInfo {
-	imgBounds := imgInfo.img.Bounds()
-	w := imgBounds.Dx()
-	h := imgBounds.Dy()
-
-	dst := image.NewRGBA(image.Rect(0, 0, w, h))
-
-	for y := range h {
-		for x := range w {
-			dst.Set(x, y, updater(x, y, imgInfo.img.At(x, y)))
-		}
-	}
-
-	imgInfo.img = dst
-	return imgInfo
-}
-
 func (imgInfo *imageInfo) ClipRRect(percentRadius float64) *imageInfo {
 	if percentRadius == 1 {
 		return imgInfo.ClipToCircle()
@@ -248,12 +279,9 @@ func (imgInfo *imageInfo) ClipRRect(percentRadius float64) *imageInfo {
 	r := math.Max(float64(imgBounds.Dx()), float64(imgBounds.Dy())) / 2
 	roundedCornerRadius := int(math.Floor(r) * percentRadius)
 
-	return imgInfo.UpdatePixels(
-		func(x, y int, c color.Color) color.Color {
-			if isOnRoundedCorner(x, y, w, h, roundedCornerRadius) {
-				return color.RGBA{}
-			}
-			return c
+	return imgInfo.clipPixels(
+		func(x, y int) bool {
+			return !isOnRoundedCorner(x, y, w, h, roundedCornerRadius)
 		},
 	)
 }
@@ -298,12 +326,9 @@ func (imgInfo *imageInfo) ClipToCircle() *imageInfo {
 	cx := w / 2
 	cy := h / 2
 
-	return imgInfo.UpdatePixels(
-		func(x, y int, c color.Color) color.Color {
-			if isPixelInsideCircle(x, y, cx, cy, r) {
-				return c
-			}
-			return color.RGBA{}
+	return imgInfo.clipPixels(
+		func(x, y int) bool {
+			return isPixelInsideCircle(x, y, cx, cy, r)
 		},
 	)
 }
@@ -325,7 +350,12 @@ func (imgInfo imageInfo) SplitPerAsset(assets []asset) *imageInfoSlice {
 func (imgInfo *imageInfo) ResizeForAsset() *imageInfo {
 	imgBounds := imgInfo.img.Bounds()
 	w, h := imgInfo.asset.CalcSize(imgBounds.Dx(), imgBounds.Dy())
-	return imgInfo.Resize(w, h)
+	if imgBounds.Dx() == w && imgBounds.Dy() == h {
+		return imgInfo
+	}
+
+	imgInfo.img = imgInfo.resizeOptions.resizeAndSharpen(imgInfo.img, w, h)
+	return imgInfo
 }
 
 func (imgInfo *imageInfo) CenterCanvaseForAsset() *imageInfo {
@@ -346,17 +376,12 @@ func (imgInfo imageInfo) SaveWithCustomName(customImageName string) error {
 
 	var dir string
 	if len(imgInfo.asset.DirName()) != 0 {
-		dir = filepath.Join(imgInfo.saveDirPath, imgInfo.asset.DirName())
+		dir = path.Join(imgInfo.saveDirPath, imgInfo.asset.DirName())
 	} else {
 		dir = imgInfo.saveDirPath
 	}
 
-	err := imgInfo.rootDir.Mkdir(dir, os.ModePerm)
-	if err != nil && !os.IsExist(err) {
-		return err
-	}
-
-	err = saveImage(imgInfo.rootDir, filepath.Join(dir, name), imgInfo.img, imgInfo.encoder)
+	err := saveImage(imgInfo.output, path.Join(dir, name), imgInfo.img, imgInfo.encoder)
 	if err != nil {
 		return err
 	}
@@ -368,242 +393,236 @@ func (imgInfo *imageInfo) SoldiColor(newColor color.Color) *imageInfo {
 	return imgInfo.ConvertColors(func(_ color.Color) color.Color { return newColor })
 }
 
-func (imgInfo *imageInfo) LinearGradient(colorsTable GradientTable, degree int) *imageInfo {
+func (imgInfo *imageInfo) LinearGradient(colorsTable GradientTable, space GradientSpace, degree int) *imageInfo {
+	imgBounds := imgInfo.img.Bounds()
+	imgInfo.img = createLinearGradient(colorsTable, space, degree, imgBounds.Dx(), imgBounds.Dy())
+	return imgInfo
+}
+
+func (imgInfo *imageInfo) RadialGradient(colorsTable GradientTable, space GradientSpace, option RadialGradientOptions) *imageInfo {
+	imgBounds := imgInfo.img.Bounds()
+	imgInfo.img = createRadialGradient(colorsTable, space, option, imgBounds.Dx(), imgBounds.Dy())
+	return imgInfo
+}
+
+func (imgInfo *imageInfo) ConicGradient(colorsTable GradientTable, space GradientSpace, startDegree int, centerX, centerY float64) *imageInfo {
 	imgBounds := imgInfo.img.Bounds()
-	imgInfo.img = createLinearGradient(colorsTable, degree, imgBounds.Dx(), imgBounds.Dy())
+	w, h := imgBounds.Dx(), imgBounds.Dy()
+	imgInfo.img = createConicGradient(colorsTable, space, startDegree, float64(w)*centerX, float64(h)*centerY, w, h)
 	return imgInfo
 }
 
-func (imgInfo *imageInfo) RadialGradient(colorsTable GradientTable) *imageInfo {
+func (imgInfo *imageInfo) DiamondGradient(colorsTable GradientTable, space GradientSpace, centerX, centerY float64) *imageInfo {
 	imgBounds := imgInfo.img.Bounds()
-	imgInfo.img = createRadialGradient(colorsTable, imgBounds.Dx(), imgBounds.Dy())
+	w, h := imgBounds.Dx(), imgBounds.Dy()
+	imgInfo.img = createDiamondGradient(colorsTable, space, float64(w)*centerX, float64(h)*centerY, w, h)
 	return imgInfo
 }
 
+// Same as [imageInfo.Copy] without cloning the image, the image is shared until one of them replaces it
+func (imgInfo imageInfo) ShallowCopy() *imageInfo {
+	return &imgInfo
+}
+
 func (imgInfo imageInfo) Copy() *imageInfo {
 	return &imageInfo{
-		img:               clone.AsRGBA(imgInfo.img),
+		img:               cloneNRGBA(imgInfo.img),
 		imagePath:         imgInfo.imagePath,
 		imageName:         imgInfo.imageName,
 		imageExt:          imgInfo.imageExt,
 		imgNameWithoutExt: imgInfo.imgNameWithoutExt,
 		encoder:           imgInfo.encoder,
 		asset:             imgInfo.asset,
-		rootDir:           imgInfo.rootDir,
+		output:            imgInfo.output,
+		ownedOutput:       imgInfo.ownedOutput,
 		saveDirPath:       imgInfo.saveDirPath,
+		resizeOptions:     imgInfo.resizeOptions,
 	}
 }
 
 // All the images should be the same width and height
 //
-// layout the images on top of each other the last image will be laid out at last
+// layout the images on top of each other the last image will be laid out at last.
+// Every pixel is the pixel of the top most image that is not fully transparent there, the pixels are not blended
 func (imgInfo *imageInfo) Stack(images ...*imageInfo) *imageInfo {
-	images = append([]*imageInfo{imgInfo}, images...)
-	slices.Reverse(images)
-
-	return imgInfo.UpdatePixels(
-		func(x, y int, _ color.Color) color.Color {
-			for _, img := range images {
-				c := img.img.At(x, y)
-				_, _, _, a := c.RGBA()
-				if a != 0 {
-					return c
-				}
-			}
-			return color.RGBA{}
-		},
-	)
-}
-
-func (imgInfo *imageInfo) StackWithNoAlpha(threshold float64, images ...*imageInfo) *imageInfo {
-	images = append([]*imageInfo{imgInfo}, images...)
-	slices.Reverse(images)
-
-	l := len(images)
-	return imgInfo.UpdatePixels(
-		func(x, y int, _ color.Color) color.Color {
-
-			for i, img := range images {
-				isLastImage := i == l-1
-				rgba := color.RGBAModel.Convert(img.img.At(x, y)).(color.RGBA)
-
-				if isColorNotTransparent(rgba) {
-					return rgba
-				}
-				if isColorFullTransparent(rgba) || isLastImage {
-					if isLastImage {
-						rgba.A = 255
-						return rgba
-					}
-					continue
-				}
-
-				// not last image and the alpha value is  0 < A < 255
-
-				if float64(rgba.A) > 255*threshold { // if the alpha is grater then threshold% then remove it from the color, or use the background color otherwise
-					rgba.A = 255
-					return rgba
-				}
+	layers := topDownLayers(images)
 
+	imgInfo.img = mapNRGBA(asNRGBA(imgInfo.img), func(x, y int, bgPx, dstPx []uint8) {
+		for _, layer := range layers {
+			if !(image.Point{x, y}).In(layer.Rect) {
 				continue
 			}
+			px := layer.Pix[layer.PixOffset(x, y):]
+			if px[3] != 0 {
+				copy(dstPx, px[:4])
+				return
+			}
+		}
+		copy(dstPx, bgPx)
+	})
 
-			return color.RGBA{R: 255, G: 0, B: 0, A: 255} // red to catch any errors
-		},
-	)
+	return imgInfo
 }
 
-// will not affect the full transparent or non transparent colors i.e. 0 and 255 value for the alpha channel
-func (imgInfo *imageInfo) RemoveAlphaOnThreshold(threshold float64) *imageInfo {
-	return imgInfo.UpdatePixels(
-		func(x, y int, c color.Color) color.Color {
-			rgba := color.RGBAModel.Convert(c).(color.RGBA)
-
-			if isColorFullTransparent(rgba) || isColorNotTransparent(rgba) {
-				return rgba
-			}
-
-			// the alpha value is  0 < A < 255
-			if float64(rgba.A) > 255*threshold {
-				rgba.A = 255
-				return rgba
+// All the images should be the same width and height
+//
+// Same as [imageInfo.Stack] but the translucent pixels are blended over the pixels below them (source over),
+// e.g: for the soft anti aliased edges of the logo when the alpha threshold is disabled.
+// Blends the straight colors in NRGBA, so the faint pixels keep their color instead of rounding to black
+func (imgInfo *imageInfo) StackOver(images ...*imageInfo) *imageInfo {
+	layers := make([]*image.NRGBA, len(images))
+	for i, img := range images {
+		layers[i] = asNRGBA(img.img)
+	}
+
+	imgInfo.img = mapNRGBA(asNRGBA(imgInfo.img), func(x, y int, bgPx, dstPx []uint8) {
+		copy(dstPx, bgPx)
+		for _, layer := range layers {
+			if !(image.Point{x, y}).In(layer.Rect) {
+				continue
 			}
+			blendOver(dstPx, layer.Pix[layer.PixOffset(x, y):])
+		}
+	})
 
-			return color.RGBA{}
-		},
-	)
-}
-
-func isColorFullTransparent(c color.Color) bool {
-	_, _, _, a := c.RGBA()
-	return a == 0
+	return imgInfo
 }
 
-func isColorNotTransparent(c color.Color) bool {
-	_, _, _, a := c.RGBA()
-	return a == 255
+// Blends the straight alpha pixel [src] over [dst] in place
+func blendOver(dst, src []uint8) {
+	srcA := uint32(src[3])
+	switch srcA {
+	case 0:
+		return
+	case 255:
+		copy(dst, src[:4])
+		return
+	}
 
+	// the alpha of the result scaled by 255, so the colors are divided once
+	dstWeight := uint32(dst[3]) * (255 - srcA)
+	outA := srcA*255 + dstWeight
+	for i := range 3 {
+		dst[i] = uint8((uint32(src[i])*srcA*255 + uint32(dst[i])*dstWeight + outA/2) / outA)
+	}
+	dst[3] = uint8((outA + 127) / 255)
 }
 
-func (imgInfo *imageInfo) RemoveAlpha() *imageInfo {
-	return imgInfo.UpdatePixels(
-		func(x, y int, c color.Color) color.Color {
-			rgba := color.RGBAModel.Convert(c).(color.RGBA)
-			rgba.A = 255
-			return rgba
-		},
-	)
+// From the top image down to the bottom one
+func topDownLayers(images []*imageInfo) []*image.NRGBA {
+	layers := make([]*image.NRGBA, len(images))
+	for i, img := range images {
+		layers[len(images)-1-i] = asNRGBA(img.img)
+	}
+	return layers
 }
 
-func (imgInfo *imageInfo) TrimWhiteSpace() *imageInfo {
-	imgBounds := imgInfo.img.Bounds()
-	w := imgBounds.Dx()
-	h := imgBounds.Dy()
-
-	wg := sync.WaitGroup{}
-	wg.Add(2)
-
-	var topTrimCount, bottomTrimCount, leftTrimCount, rightTrimCount int
-	go func() {
-		defer wg.Done()
-		topTrimCount, bottomTrimCount = reportX(imgInfo.img)
-	}()
-	go func() {
-		defer wg.Done()
-		leftTrimCount, rightTrimCount = reportY(imgInfo.img)
-	}()
-
-	wg.Wait()
-
-	dst := image.NewRGBA(image.Rect(0, 0, w-rightTrimCount-leftTrimCount, h-bottomTrimCount-topTrimCount))
-	draw.Draw(dst, dst.Rect, imgInfo.img, image.Point{leftTrimCount, topTrimCount}, draw.Src)
-
-	imgInfo.img = dst
-	return imgInfo
+// Same as [imageInfo.StackWithBgAlpha] but the result is fully opaque, for the targets that does not allow transparency
+func (imgInfo *imageInfo) StackWithNoAlpha(threshold float64, images ...*imageInfo) *imageInfo {
+	return imgInfo.stackOnThreshold(threshold, false, images)
 }
 
-func reportX(img image.Image) (top, bottom int) {
-	imgBounds := img.Bounds()
-	w := imgBounds.Dx()
-	h := imgBounds.Dy()
-
-	canTrimX := func(y int) bool {
-		for x := range w {
-			if isColorFullTransparent(img.At(x, y)) {
+// All the images should be the same width and height
+//
+// layout the images on top of each other the last image will be laid out at last.
+// The pixels of the top images that are more transparent than the threshold are dropped, the others will be opaque.
+// Only the alpha of the first image (the background) is kept.
+func (imgInfo *imageInfo) StackWithBgAlpha(threshold float64, images ...*imageInfo) *imageInfo {
+	return imgInfo.stackOnThreshold(threshold, true, images)
+}
+
+func (imgInfo *imageInfo) stackOnThreshold(threshold float64, keepBgAlpha bool, images []*imageInfo) *imageInfo {
+	// from the top image down to the one above the background
+	layers := topDownLayers(images)
+	bg := asNRGBA(imgInfo.img)
+	alphaThreshold := 255 * threshold
+
+	imgInfo.img = mapNRGBA(bg, func(x, y int, bgPx, dstPx []uint8) {
+		for _, layer := range layers {
+			if !(image.Point{x, y}).In(layer.Rect) {
 				continue
 			}
-			return false
-		}
-		return true
-	}
+			px := layer.Pix[layer.PixOffset(x, y):]
 
-	var didTopStoped, didBottomStoped bool
+			a := px[3]
+			if a == 0 {
+				continue
+			}
 
-	half := int(math.Floor(float64(h) / 2))
-	y := 0
-	for {
-		if (didTopStoped && didBottomStoped) || (y >= half) {
-			break
+			// if the alpha is grater then threshold% then remove it from the color, or use the colors below it otherwise
+			if a == 255 || float64(a) > alphaThreshold {
+				copy(dstPx, px[:3])
+				dstPx[3] = 255
+				return
+			}
 		}
 
-		if canTrimX(y) {
-			top++
-		} else {
-			didTopStoped = true
+		copy(dstPx, bgPx)
+		if !keepBgAlpha {
+			dstPx[3] = 255
 		}
+	})
+
+	return imgInfo
+}
 
-		if canTrimX(h - y - 1) {
-			bottom++
-		} else {
-			didBottomStoped = true
+// will not affect the full transparent or non transparent colors i.e. 0 and 255 value for the alpha channel
+func (imgInfo *imageInfo) RemoveAlphaOnThreshold(threshold float64) *imageInfo {
+	alphaThreshold := 255 * threshold
+
+	imgInfo.img = mapNRGBA(asNRGBA(imgInfo.img), func(_, _ int, srcPx, dstPx []uint8) {
+		a := srcPx[3]
+		if a == 255 {
+			copy(dstPx, srcPx)
+			return
 		}
 
-		y++
-	}
+		// the alpha value is  0 < A < 255, the fully transparent pixels stay zero
+		if a != 0 && float64(a) > alphaThreshold {
+			copy(dstPx, srcPx)
+			dstPx[3] = 255
+		}
+	})
 
-	return top, bottom
+	return imgInfo
 }
 
-func reportY(img image.Image) (left, right int) {
-	imgBounds := img.Bounds()
-	w := imgBounds.Dx()
-	h := imgBounds.Dy()
-
-	canTrimY := func(x int) bool {
-		for y := range h {
-			if isColorFullTransparent(img.At(x, y)) {
-				continue
-			}
-			return false
+// Makes every pixel opaque keeping its color, i.e. the color is not premultiplied by the alpha anymore.
+// The fully transparent pixels have no color and become black
+func (imgInfo *imageInfo) RemoveAlpha() *imageInfo {
+	imgInfo.img = mapNRGBA(asNRGBA(imgInfo.img), func(_, _ int, srcPx, dstPx []uint8) {
+		if srcPx[3] != 0 {
+			copy(dstPx, srcPx)
 		}
-		return true
-	}
+		dstPx[3] = 255
+	})
+	return imgInfo
+}
 
-	var didLeftStoped, didRightStoped bool
+// Trims the fully transparent edges
+func (imgInfo *imageInfo) TrimWhiteSpace() *imageInfo {
+	return imgInfo.Trim(TrimOptions{})
+}
 
-	half := int(math.Floor(float64(w) / 2))
-	x := 0
-	for {
-		if (didLeftStoped && didRightStoped) || (x >= half) {
-			break
-		}
+// Trims the edges that are background per [TrimOptions], with the background keyed out if [TrimOptions.KeyOut] is set.
+// An image that is all background is kept as is
+func (imgInfo *imageInfo) Trim(options TrimOptions) *imageInfo {
+	img := asNRGBA(imgInfo.img)
 
-		if canTrimY(x) {
-			left++
-		} else {
-			didLeftStoped = true
-		}
+	borderColor := options.borderColorFor(img)
+	isBackground := backgroundMatcher(borderColor, options.Tolerance, options.AlphaThreshold)
 
-		if canTrimY(w - x - 1) {
-			right++
-		} else {
-			didRightStoped = true
-		}
+	rect, ok := contentRect(img, isBackground, options.Coverage)
+	if !ok {
+		return imgInfo
+	}
 
-		x++
+	if options.KeyOut && borderColor != nil {
+		img = keyOutBackground(img, *borderColor, options.Tolerance)
 	}
 
-	return left, right
+	imgInfo.img = placeNRGBA(img, rect.Dx(), rect.Dy(), rect.Min.Mul(-1))
+	return imgInfo
 }
 
 func (imgInfo *imageInfo) CropToSquare() *imageInfo {
@@ -619,10 +638,7 @@ func (imgInfo *imageInfo) CropToSquare() *imageInfo {
 
 	size := int(math.Min(float64(w), float64(h)))
 
-	dst := image.NewRGBA(image.Rect(0, 0, size, size))
-	draw.Draw(dst, dst.Rect, imgInfo.img, image.Point{cx - size/2, cy - size/2}, draw.Src)
-
-	imgInfo.img = dst
+	imgInfo.img = placeNRGBA(imgInfo.img, size, size, image.Pt(size/2-cx, size/2-cy))
 	return imgInfo
 }
 
@@ -648,9 +664,6 @@ func (imgInfo *imageInfo) CenterInCanvas(canvasW, canvasH int) *imageInfo {
 	cx := w / 2
 	cy := h / 2
 
-	dst := image.NewRGBA(image.Rect(0, 0, canvasW, canvasH))
-	draw.Draw(dst, dst.Rect, imgInfo.img, image.Point{-canvasW/2 + cx, -canvasH/2 + cy}, draw.Src)
-
-	imgInfo.img = dst
+	imgInfo.img = placeNRGBA(imgInfo.img, canvasW, canvasH, image.Pt(canvasW/2-cx, canvasH/2-cy))
 	return imgInfo
 }
@@ -0,0 +1,277 @@
+package assetsgen
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func solidNRGBA(w, h int, c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	return img
+}
+
+func TestStack(t *testing.T) {
+	tests := []struct {
+		name     string
+		bg, top  color.NRGBA
+		want     color.NRGBA
+		wantOver color.NRGBA
+	}{
+		{
+			name:     "opaque top",
+			bg:       color.NRGBA{0, 0, 255, 255},
+			top:      color.NRGBA{255, 0, 0, 255},
+			want:     color.NRGBA{255, 0, 0, 255},
+			wantOver: color.NRGBA{255, 0, 0, 255},
+		},
+		{
+			name:     "transparent top",
+			bg:       color.NRGBA{0, 0, 255, 128},
+			top:      color.NRGBA{255, 0, 0, 0},
+			want:     color.NRGBA{0, 0, 255, 128},
+			wantOver: color.NRGBA{0, 0, 255, 128},
+		},
+		{
+			name:     "translucent top over opaque",
+			bg:       color.NRGBA{0, 0, 255, 255},
+			top:      color.NRGBA{255, 0, 0, 128},
+			want:     color.NRGBA{255, 0, 0, 128},
+			wantOver: color.NRGBA{128, 0, 127, 255},
+		},
+		{
+			// the 8 bits premultiplied form rounds this one to black
+			name:     "faint top over transparent",
+			bg:       color.NRGBA{},
+			top:      color.NRGBA{200, 100, 50, 1},
+			want:     color.NRGBA{200, 100, 50, 1},
+			wantOver: color.NRGBA{200, 100, 50, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bg := &imageInfo{img: solidNRGBA(2, 2, tt.bg)}
+			top := &imageInfo{img: solidNRGBA(2, 2, tt.top)}
+			if got := asNRGBA(bg.Copy().Stack(top).img).NRGBAAt(1, 1); got != tt.want {
+				t.Errorf("Stack() = %v, want %v", got, tt.want)
+			}
+			if got := asNRGBA(bg.Copy().StackOver(top).img).NRGBAAt(1, 1); got != tt.wantOver {
+				t.Errorf("StackOver() = %v, want %v", got, tt.wantOver)
+			}
+		})
+	}
+}
+
+// Every alpha value with changing colors, not square to catch mixed up axes
+func alphaRampNRGBA() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 32, 24))
+	for y := range 24 {
+		for x := range 32 {
+			img.SetNRGBA(x, y, color.NRGBA{uint8(x * 37), uint8(y * 53), uint8(x*y + 90), uint8(x*8 + y)})
+		}
+	}
+	return img
+}
+
+// The At/Set implementations the pixel buffer operations replaced
+func legacyUpdatePixels(img image.Image, updater func(x, y int, c color.Color) color.Color) image.Image {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			dst.Set(x, y, updater(x, y, img.At(x, y)))
+		}
+	}
+	return dst
+}
+
+func legacyClipRRect(img image.Image, percentRadius float64) image.Image {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	r := int(math.Floor(math.Max(float64(w), float64(h))/2) * percentRadius)
+	return legacyUpdatePixels(img, func(x, y int, c color.Color) color.Color {
+		if isOnRoundedCorner(x, y, w, h, r) {
+			return color.RGBA{}
+		}
+		return c
+	})
+}
+
+func legacyRemoveAlphaOnThreshold(img image.Image, threshold float64) image.Image {
+	return legacyUpdatePixels(img, func(x, y int, c color.Color) color.Color {
+		rgba := color.RGBAModel.Convert(c).(color.RGBA)
+		if rgba.A == 0 || rgba.A == 255 {
+			return rgba
+		}
+		if float64(rgba.A) > 255*threshold {
+			rgba.A = 255
+			return rgba
+		}
+		return color.RGBA{}
+	})
+}
+
+func legacyRemoveAlpha(img image.Image) image.Image {
+	return legacyUpdatePixels(img, func(x, y int, c color.Color) color.Color {
+		rgba := color.RGBAModel.Convert(c).(color.RGBA)
+		rgba.A = 255
+		return rgba
+	})
+}
+
+func TestPixelOpsMatchAtSet(t *testing.T) {
+	tests := []struct {
+		name   string
+		op     func(*imageInfo) *imageInfo
+		legacy func(image.Image) image.Image
+	}{
+		{
+			name:   "ClipRRect 0.25",
+			op:     func(i *imageInfo) *imageInfo { return i.ClipRRect(0.25) },
+			legacy: func(img image.Image) image.Image { return legacyClipRRect(img, 0.25) },
+		},
+		{
+			name:   "ClipRRect 0.8",
+			op:     func(i *imageInfo) *imageInfo { return i.ClipRRect(0.8) },
+			legacy: func(img image.Image) image.Image { return legacyClipRRect(img, 0.8) },
+		},
+		{
+			name:   "RemoveAlphaOnThreshold 0",
+			op:     func(i *imageInfo) *imageInfo { return i.RemoveAlphaOnThreshold(0) },
+			legacy: func(img image.Image) image.Image { return legacyRemoveAlphaOnThreshold(img, 0) },
+		},
+		{
+			name:   "RemoveAlphaOnThreshold 0.5",
+			op:     func(i *imageInfo) *imageInfo { return i.RemoveAlphaOnThreshold(0.5) },
+			legacy: func(img image.Image) image.Image { return legacyRemoveAlphaOnThreshold(img, 0.5) },
+		},
+		{
+			name:   "RemoveAlphaOnThreshold 1",
+			op:     func(i *imageInfo) *imageInfo { return i.RemoveAlphaOnThreshold(1) },
+			legacy: func(img image.Image) image.Image { return legacyRemoveAlphaOnThreshold(img, 1) },
+		},
+		{
+			name:   "RemoveAlpha",
+			op:     func(i *imageInfo) *imageInfo { return i.RemoveAlpha() },
+			legacy: legacyRemoveAlpha,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := alphaRampNRGBA()
+			want := tt.legacy(src)
+			got := tt.op(&imageInfo{img: src}).img
+			if got.Bounds() != want.Bounds() {
+				t.Fatalf("bounds = %v, want %v", got.Bounds(), want.Bounds())
+			}
+
+			// the old results were premultiplied, compare in that form
+			for y := range want.Bounds().Dy() {
+				for x := range want.Bounds().Dx() {
+					g := color.RGBAModel.Convert(got.At(x, y))
+					w := color.RGBAModel.Convert(want.At(x, y)).(color.RGBA)
+
+					// the only intended difference: a translucent pixel made opaque keeps its straight color,
+					// the At/Set versions darkened it by its alpha (see TestResizeLinearRingGolden)
+					if s := src.NRGBAAt(x, y); w.A == 255 && s.A != 0 && s.A != 255 {
+						w = color.RGBA{s.R, s.G, s.B, 255}
+					}
+
+					if g != w {
+						t.Fatalf("pixel (%d,%d) of %v = %v, want %v", x, y, src.NRGBAAt(x, y), g, w)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCopy(t *testing.T) {
+	// the 8 bits premultiplied form rounds this one to black
+	faint := color.NRGBA{200, 100, 50, 1}
+	src := &imageInfo{img: solidNRGBA(2, 2, faint)}
+
+	copied := src.Copy()
+	img, ok := copied.img.(*image.NRGBA)
+	if !ok {
+		t.Fatalf("Copy() image is %T, want *image.NRGBA", copied.img)
+	}
+	if got := img.NRGBAAt(1, 1); got != faint {
+		t.Errorf("Copy() pixel = %v, want %v", got, faint)
+	}
+
+	img.SetNRGBA(0, 0, color.NRGBA{})
+	if got := asNRGBA(src.img).NRGBAAt(0, 0); got != faint {
+		t.Errorf("changing the copy changed the source to %v", got)
+	}
+}
+
+// A red disc with an anti-aliased edge on transparent pixels, the largest asset size
+func benchLogoNRGBA() *image.NRGBA {
+	const size = 1024
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	c, r := float64(size)/2, float64(size)*0.4
+	for y := range size {
+		for x := range size {
+			d := math.Hypot(float64(x)+0.5-c, float64(y)+0.5-c)
+			a := math.Max(0, math.Min(1, r-d+0.5))
+			img.SetNRGBA(x, y, color.NRGBA{R: 220, G: 40, B: 40, A: uint8(a*255 + 0.5)})
+		}
+	}
+	return img
+}
+
+func BenchmarkUpdateNRGBAPixels(b *testing.B) {
+	logo := benchLogoNRGBA()
+	b.ReportAllocs()
+	for b.Loop() {
+		(&imageInfo{img: logo}).UpdateNRGBAPixels(func(_, _ int, c color.NRGBA) color.NRGBA {
+			return color.NRGBA{R: c.G, G: c.B, B: c.R, A: c.A}
+		})
+	}
+}
+
+func BenchmarkStack(b *testing.B) {
+	logo := &imageInfo{img: benchLogoNRGBA()}
+	bg := solidNRGBA(1024, 1024, color.NRGBA{0, 0, 255, 255})
+
+	benchmarks := []struct {
+		name  string
+		stack func(bg *imageInfo) *imageInfo
+	}{
+		{"Stack", func(bg *imageInfo) *imageInfo { return bg.Stack(logo) }},
+		{"StackOver", func(bg *imageInfo) *imageInfo { return bg.StackOver(logo) }},
+		{"StackWithNoAlpha", func(bg *imageInfo) *imageInfo { return bg.StackWithNoAlpha(0.5, logo) }},
+		{"StackWithBgAlpha", func(bg *imageInfo) *imageInfo { return bg.StackWithBgAlpha(0.5, logo) }},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				bm.stack(&imageInfo{img: bg})
+			}
+		})
+	}
+}
+
+func BenchmarkClipRRect(b *testing.B) {
+	logo := benchLogoNRGBA()
+	b.ReportAllocs()
+	for b.Loop() {
+		(&imageInfo{img: logo}).ClipRRect(0.25)
+	}
+}
+
+func BenchmarkRemoveAlpha(b *testing.B) {
+	logo := benchLogoNRGBA()
+	b.ReportAllocs()
+	for b.Loop() {
+		(&imageInfo{img: logo}).RemoveAlpha()
+	}
+}
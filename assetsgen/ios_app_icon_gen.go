@@ -3,7 +3,7 @@ package assetsgen
 import (
 	"encoding/json"
 	"fmt"
-	"path/filepath"
+	"path"
 	"slices"
 
 	"github.com/lucasb-eyer/go-colorful"
@@ -159,12 +159,42 @@ var iosAppIconDpis = []asset{
 	},
 }
 
+// The single size layout is the only one that supports the dark appearance (iOS 18+), Xcode rejects
+// the appearances in the per size layout of [iosAppIconDpis]. It's generated instead of it when the dark icon is asked for
+var iosSingleSizeAppIconDpis = []asset{
+	iosAppIconDpiAsset{
+		Filename: "AppIcon~ios-marketing",
+		Idiom:    "universal",
+		Platform: "ios",
+		SizeName: "1024x1024",
+		Size:     1024,
+	},
+	iosDarkAppIconDpi,
+}
+
+// Unlike the light icon it can be transparent
+var iosDarkAppIconDpi = iosAppIconDpiAsset{
+	Filename:    "AppIcon-dark~ios-marketing",
+	Idiom:       "universal",
+	Platform:    "ios",
+	SizeName:    "1024x1024",
+	Size:        1024,
+	Appearances: []iosAppIconAppearance{{Appearance: "luminosity", Value: "dark"}},
+}
+
+type iosAppIconAppearance struct {
+	Appearance string `json:"appearance"`
+	Value      string `json:"value"`
+}
+
 type iosAppIconDpiAsset struct {
-	Filename string `json:"filename"`
-	Idiom    string `json:"idiom"`
-	Scale    string `json:"Scale"`
-	Size     int    `json:"-"`
-	SizeName string `json:"size"`
+	Filename    string                 `json:"filename"`
+	Idiom       string                 `json:"idiom"`
+	Platform    string                 `json:"platform,omitempty"`
+	Scale       string                 `json:"Scale,omitempty"`
+	Size        int                    `json:"-"`
+	SizeName    string                 `json:"size"`
+	Appearances []iosAppIconAppearance `json:"appearances,omitempty"`
 }
 
 func (a iosAppIconDpiAsset) Name() string {
@@ -191,59 +221,92 @@ type IosAppIconOptions struct {
 	// removes the white spaces from the edges of the logo
 	TrimWhiteSpace bool
 
+	// The background color to trim and key out, e.g: for JPG logos on white. Transparent only by default
+	Trim TrimOptions
+
+	// Between [0..1] moves the logo toward its visual center (the alpha weighted centroid) instead of the center
+	// of its bounding box. 0 to disable, 0.5 suits most triangles and letters like "L"
+	OpticalCentering float64
+
 	MaskColor *colorful.Color
+
+	// The background of the dark appearance icon (iOS 18+). Unlike the light icon, its transparency is kept. nil to skip the dark icon.
+	// With it the single size icon set is generated (1024px light and dark icons) instead of the per size icons
+	DarkBgIcon BackgroundIcon
+
+	// The filter and the sharpening used to resize to the asset sizes
+	Resize ResizeOptions
+
+	// Where the files are written to. Nil falls back to [RootFolderName] in the working directory, see [NewDirSink]
+	Output OutputSink
 }
 
 func GenerateAppIconForIos(imagePath string, option IosAppIconOptions) error {
-	logoImage, err := genLogoImageForIos(imagePath, option)
+	src, err := LoadSourceImage(imagePath)
 	if err != nil {
 		return err
 	}
-	defer logoImage.rootDir.Close()
+	return GenerateAppIconForIosFromSource(src, option)
+}
 
-	bgImage, err := option.BgIcon.generateImgInfo(logoImage)
+func GenerateAppIconForIosFromSource(src *SourceImage, option IosAppIconOptions) error {
+	logoImage, err := newImageInfoFromSource(
+		src,
+		src.logo(option.TrimWhiteSpace, option.Trim, option.OpticalCentering, option.Padding, -1, option.MaskColor),
+		option.Output,
+		path.Join(PlatformTypeIos, "Assets.xcassets", "AppIcon.appiconset"),
+	)
 	if err != nil {
 		return err
 	}
+	defer logoImage.CloseOutput()
+	logoImage.SetResizeOptions(option.Resize.withDefaultSpace(ResizeSpaceLinear)).
+		If(option.Trim.KeyOut, logoImage.EncodeWithAlpha)
 
-	err = generateIosAppIcon(logoImage, bgImage, option.AlphaThreshold, iosAppIconDpis)
+	bgImage, err := option.BgIcon.generateImgInfo(logoImage)
 	if err != nil {
 		return err
 	}
 
-	err = generateContentsJson(logoImage, iosAppIconDpis)
+	if option.DarkBgIcon == nil {
+		err = generateIosAppIcon(logoImage, bgImage, option.AlphaThreshold, iosAppIconDpis)
+		if err != nil {
+			return err
+		}
+		return generateContentsJson(logoImage, iosAppIconDpis)
+	}
+
+	darkBgImage, err := option.DarkBgIcon.generateImgInfo(logoImage)
 	if err != nil {
 		return err
 	}
 
-	return nil
-
-}
+	err = generateIosAppIcon(logoImage, bgImage, option.AlphaThreshold, iosSingleSizeAppIconDpis[:1])
+	if err != nil {
+		return err
+	}
 
-func genLogoImageForIos(imagePath string, option IosAppIconOptions) (*imageInfo, error) {
-	logoImage, err := newImageInfo(
-		imagePath,
-		filepath.Join(PlatformTypeIos, "Assets.xcassets", "AppIcon.appiconset"),
-	)
+	err = generateIosDarkAppIcon(logoImage, darkBgImage, option.AlphaThreshold)
 	if err != nil {
-		return logoImage, err
+		return err
 	}
 
-	pad := calPadding(logoImage.img, option.Padding)
+	dpis := iosSingleSizeAppIconDpis
+	err = generateContentsJson(logoImage, dpis)
+	if err != nil {
+		return err
+	}
 
-	logoImage.
-		If(option.TrimWhiteSpace, logoImage.TrimWhiteSpace).
-		SquareImageWithEmptyPixels(pad).
-		If(option.MaskColor != nil, func() *imageInfo { return logoImage.ConvertNoneOpaqueToColor(*option.MaskColor) })
+	return nil
 
-	return logoImage, nil
 }
 
 func generateIosAppIcon(logoImage *imageInfo, bgImage *imageInfo, alphaThreshold float64, iosAppIconDpis []asset) error {
 	imgs := bgImage.
 		IfElse(
 			alphaThreshold < 0,
-			func() *imageInfo { return bgImage.Stack(logoImage) },
+			// the app store rejects the icons with an alpha channel
+			func() *imageInfo { return bgImage.StackOver(logoImage).RemoveAlpha() },
 			func() *imageInfo { return bgImage.StackWithNoAlpha(alphaThreshold, logoImage) },
 		).
 		SplitPerAsset(iosAppIconDpis).
@@ -259,6 +322,19 @@ func generateIosAppIcon(logoImage *imageInfo, bgImage *imageInfo, alphaThreshold
 	return nil
 }
 
+func generateIosDarkAppIcon(logoImage *imageInfo, darkBgImage *imageInfo, alphaThreshold float64) error {
+	darkBgImage.asset = iosDarkAppIconDpi
+
+	return darkBgImage.
+		IfElse(
+			alphaThreshold < 0,
+			func() *imageInfo { return darkBgImage.StackOver(logoImage) },
+			func() *imageInfo { return darkBgImage.StackWithBgAlpha(alphaThreshold, logoImage) },
+		).
+		ResizeForAsset().
+		SaveWithCustomName(iosDarkAppIconDpi.Name())
+}
+
 func generateContentsJson(logoImage *imageInfo, dpis []asset) error {
 	type GenInfo struct {
 		Author  string `json:"author"`
@@ -289,15 +365,5 @@ func generateContentsJson(logoImage *imageInfo, dpis []asset) error {
 		return err
 	}
 
-	file, err := logoImage.rootDir.Create(filepath.Join(logoImage.saveDirPath, "Contents.json"))
-	if err != nil {
-		return err
-	}
-
-	_, err = file.Write(jsonOut)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return writeFile(logoImage.output, path.Join(logoImage.saveDirPath, "Contents.json"), jsonOut)
 }
@@ -0,0 +1,516 @@
+package assetsgen
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var errNotPNG = errors.New("not a PNG")
+
+type LintSeverity string
+
+const (
+	// Rejected by the store or broken on the device
+	LintSeverityError LintSeverity = "error"
+	// Works, but probably not as intended
+	LintSeverityWarning LintSeverity = "warning"
+)
+
+type LintIssue struct {
+	Severity LintSeverity
+	Path     string
+	Message  string
+}
+
+func (i LintIssue) String() string {
+	return fmt.Sprint(i.Severity, ": ", i.Path, ": ", i.Message)
+}
+
+func HasLintErrors(issues []LintIssue) bool {
+	return slices.ContainsFunc(issues, func(i LintIssue) bool { return i.Severity == LintSeverityError })
+}
+
+// The folders to lint, the empty or not existing ones are skipped
+type LintDirs struct {
+	// e.g: app/src/main/res
+	AndroidRes string
+	// The Google Play logos are the PNGs in its root, e.g: app/src/main/ic_launcher-playstore.png
+	AndroidMain string
+	// e.g: Runner/Assets.xcassets/AppIcon.appiconset
+	IosAppIconSet string
+
+	// The glob of the notification icons in [LintDirs.AndroidRes], e.g: */ic_notification.png. Empty for the
+	// prefix of the generated ones, see [DefaultNotificationIconsPattern]. A set pattern that matches nothing is reported
+	NotificationIcons string
+}
+
+// The notification icons the generators write, and the ones Android Studio creates
+const DefaultNotificationIconsPattern = "*/ic_stat_*.png"
+
+// The folders the generators save in, [outputDir] empty for [RootFolderName]
+func OutputLintDirs(outputDir string) LintDirs {
+	if outputDir == "" {
+		outputDir = RootFolderName
+	}
+	return LintDirs{
+		AndroidRes:    filepath.Join(outputDir, PlatformTypeAndroid, "res"),
+		AndroidMain:   filepath.Join(outputDir, PlatformTypeAndroid, "main"),
+		IosAppIconSet: filepath.Join(outputDir, PlatformTypeIos, "Assets.xcassets", "AppIcon.appiconset"),
+	}
+}
+
+const (
+	googlePlayLogoSize     = 512
+	googlePlayLogoMaxBytes = 1024 * 1024
+
+	// The channels of the notification icon pixels should be at least this to be seen as white
+	notificationIconMinWhite = 250
+)
+
+// Checks the icons against the requirements of the stores and the platforms. The sizes are checked against the same
+// asset tables the generators use. The issues are sorted by path
+func Lint(dirs LintDirs) ([]LintIssue, error) {
+	var issues []LintIssue
+
+	linters := []struct {
+		dir  string
+		lint func(l *linter) error
+	}{
+		{dirs.AndroidRes, func(l *linter) error { return lintAndroidRes(l, dirs.NotificationIcons) }},
+		{dirs.AndroidMain, lintGooglePlayLogos},
+		{dirs.IosAppIconSet, lintIosAppIconSet},
+	}
+
+	for _, v := range linters {
+		if v.dir == "" {
+			continue
+		}
+		if _, err := os.Stat(v.dir); os.IsNotExist(err) {
+			continue
+		}
+
+		l := &linter{dir: v.dir, fsys: os.DirFS(v.dir)}
+		err := v.lint(l)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, l.issues...)
+	}
+
+	slices.SortStableFunc(issues, func(a, b LintIssue) int { return strings.Compare(a.Path, b.Path) })
+	return issues, nil
+}
+
+type linter struct {
+	dir    string
+	fsys   fs.FS
+	issues []LintIssue
+}
+
+func (l *linter) report(severity LintSeverity, name string, format string, args ...any) {
+	l.issues = append(l.issues, LintIssue{
+		Severity: severity,
+		Path:     filepath.Join(l.dir, filepath.FromSlash(name)),
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func lintAndroidRes(l *linter, notificationIcons string) error {
+	xmlPaths, err := fs.Glob(l.fsys, "*-anydpi*/*.xml")
+	if err != nil {
+		return err
+	}
+
+	for _, xmlPath := range xmlPaths {
+		data, err := fs.ReadFile(l.fsys, xmlPath)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(path.Base(xmlPath), ".xml")
+
+		for _, m := range adaptiveIconLayerRegexp.FindAllStringSubmatch(string(data), -1) {
+			resType, resName := m[2], m[3]
+
+			if resType == "color" {
+				found, err := hasAndroidColor(l.fsys, resName)
+				if err != nil {
+					return err
+				}
+				if !found {
+					l.report(LintSeverityError, xmlPath, "references @color/%s that isn't in the values", resName)
+				}
+				continue
+			}
+
+			found, err := hasAndroidDrawable(l.fsys, resType, resName)
+			if err != nil {
+				return err
+			}
+			if !found {
+				l.report(LintSeverityError, xmlPath, "references @%s/%s that doesn't exist", resType, resName)
+				continue
+			}
+
+			err = l.lintDpiSizes(resName, androidAdaptiveAppIconLayerDpisV26)
+			if err != nil {
+				return err
+			}
+		}
+
+		// the legacy icons for the launchers before v26
+		for _, legacy := range []string{name, fmt.Sprint(name, "_round")} {
+			err = l.lintDpiSizes(legacy, androidAppIconDpisLegacyLayer)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	notificationPattern := notificationIcons
+	if notificationPattern == "" {
+		notificationPattern = DefaultNotificationIconsPattern
+	}
+	notificationPaths, err := fs.Glob(l.fsys, notificationPattern)
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, notificationPattern)
+	}
+	if len(notificationPaths) == 0 && notificationIcons != "" {
+		l.report(LintSeverityWarning, notificationIcons, "no notification icons found")
+	}
+	var notificationNames []string
+	for _, p := range notificationPaths {
+		notificationNames = append(notificationNames, strings.TrimSuffix(path.Base(p), path.Ext(p)))
+		err = l.lintNotificationIcon(p)
+		if err != nil {
+			return err
+		}
+	}
+	slices.Sort(notificationNames)
+	for _, name := range slices.Compact(notificationNames) {
+		err = l.lintDpiSizes(name, androidNotificationIconDpis)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Checks every density of the table has the png of the name with the size of the table.
+// The names without any png (e.g: vector drawables) are skipped
+func (l *linter) lintDpiSizes(name string, dpis func(androidFolderName string) []asset) error {
+	for _, folder := range []AndroidFolderName{AndroidFolderMipmap, AndroidFolderDrawable} {
+		assets := dpis(string(folder))
+
+		var missing []string
+		found := false
+		for _, a := range assets {
+			p := path.Join(a.DirName(), fmt.Sprint(name, ".png"))
+			header, err := readPNGHeader(l.fsys, p)
+			if errors.Is(err, fs.ErrNotExist) {
+				missing = append(missing, a.DirName())
+				continue
+			}
+			found = true
+			if errors.Is(err, errNotPNG) {
+				l.report(LintSeverityError, p, "is not a PNG")
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			w, h := a.CalcSize(0, 0)
+			if header.width != w || header.height != h {
+				l.report(LintSeverityError, p, "is %dx%d, expected %dx%d for %s", header.width, header.height, w, h, a.Name())
+			}
+		}
+
+		if found && len(missing) != 0 {
+			l.report(LintSeverityWarning, path.Join(fmt.Sprint(folder, "-*"), fmt.Sprint(name, ".png")), "missing the dpi buckets %s", strings.Join(missing, ", "))
+		}
+	}
+	return nil
+}
+
+// The system only uses the alpha of the notification icons, the colors are lost and an opaque icon is a white square
+func (l *linter) lintNotificationIcon(p string) error {
+	img, err := openPreviewImage(l.fsys, p)
+	if err != nil {
+		l.report(LintSeverityError, p, "can't decode: %v", err)
+		return nil
+	}
+
+	src := asNRGBA(img)
+	nonWhite, transparent := 0, 0
+	for i := 0; i < len(src.Pix); i += 4 {
+		px := src.Pix[i : i+4 : i+4]
+		if px[3] != 255 {
+			transparent++
+		}
+		if px[3] != 0 && min(px[0], px[1], px[2]) < notificationIconMinWhite {
+			nonWhite++
+		}
+	}
+
+	if nonWhite != 0 {
+		l.report(LintSeverityError, p, "has %d non white pixels, the notification icons should be white on transparent", nonWhite)
+	}
+	if transparent == 0 {
+		l.report(LintSeverityError, p, "is fully opaque, it's shown as a white square")
+	}
+	return nil
+}
+
+func hasAndroidColor(fsys fs.FS, name string) (bool, error) {
+	xmlPaths, err := fs.Glob(fsys, "values*/*.xml")
+	if err != nil {
+		return false, err
+	}
+	for _, xmlPath := range xmlPaths {
+		data, err := fs.ReadFile(fsys, xmlPath)
+		if err != nil {
+			return false, err
+		}
+		for _, m := range androidColorRegexp.FindAllStringSubmatch(string(data), -1) {
+			if m[1] == name {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// Any file of the name in any qualified folder of the type, e.g: mipmap-xxhdpi/ic_launcher_foreground.webp or drawable/ic_launcher_foreground.xml
+func hasAndroidDrawable(fsys fs.FS, resType, name string) (bool, error) {
+	for _, dirPattern := range []string{resType, fmt.Sprint(resType, "-*")} {
+		matches, err := fs.Glob(fsys, path.Join(dirPattern, fmt.Sprint(name, ".*")))
+		if err != nil {
+			return false, err
+		}
+		if len(matches) != 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// The PNGs in the root of the main folder: exactly 512x512, 32-bit and up to 1MB
+func lintGooglePlayLogos(l *linter) error {
+	pngPaths, err := fs.Glob(l.fsys, "*.png")
+	if err != nil {
+		return err
+	}
+
+	for _, p := range pngPaths {
+		header, err := readPNGHeader(l.fsys, p)
+		if errors.Is(err, errNotPNG) {
+			l.report(LintSeverityError, p, "is not a PNG")
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if header.width != googlePlayLogoSize || header.height != googlePlayLogoSize {
+			l.report(LintSeverityError, p, "is %dx%d, Google Play asks for exactly %dx%d", header.width, header.height, googlePlayLogoSize, googlePlayLogoSize)
+		}
+		if header.bitDepth != 8 || header.colorType != pngColorTypeRGBA {
+			l.report(LintSeverityError, p, "is %d-bit, Google Play asks for 32-bit PNG (8 bits RGBA)", header.bitsPerPixel())
+		}
+		if header.fileSize > googlePlayLogoMaxBytes {
+			l.report(LintSeverityError, p, "is %dKB, Google Play accepts up to 1024KB", header.fileSize/1024)
+		}
+	}
+	return nil
+}
+
+type iosContentsJson struct {
+	Images []iosContentsJsonImage `json:"images"`
+}
+
+type iosContentsJsonImage struct {
+	Filename    string                 `json:"filename"`
+	Idiom       string                 `json:"idiom"`
+	Scale       string                 `json:"scale"`
+	Size        string                 `json:"size"`
+	Appearances []iosAppIconAppearance `json:"appearances"`
+}
+
+// Checks the Contents.json entries point to files of the right size, and covers the sizes of [iosAppIconDpis]
+// or the single size layout of [iosSingleSizeAppIconDpis]
+func lintIosAppIconSet(l *linter) error {
+	data, err := fs.ReadFile(l.fsys, "Contents.json")
+	if errors.Is(err, fs.ErrNotExist) {
+		l.report(LintSeverityError, "Contents.json", "is missing")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var contents iosContentsJson
+	err = json.Unmarshal(data, &contents)
+	if err != nil {
+		l.report(LintSeverityError, "Contents.json", "is not valid: %v", err)
+		return nil
+	}
+
+	dpis := iosAppIconDpis
+	if slices.ContainsFunc(contents.Images, func(e iosContentsJsonImage) bool { return e.Idiom == "universal" }) {
+		dpis = iosSingleSizeAppIconDpis
+	}
+
+	for _, v := range dpis {
+		dpi := v.(iosAppIconDpiAsset)
+		covered := slices.ContainsFunc(contents.Images, func(e iosContentsJsonImage) bool {
+			return e.Idiom == dpi.Idiom && e.Scale == dpi.Scale && e.Size == dpi.SizeName &&
+				slices.Equal(e.Appearances, dpi.Appearances) && e.Filename != ""
+		})
+		if !covered {
+			l.report(LintSeverityWarning, "Contents.json", "has no %s icon", iosDpiDescription(dpi))
+		}
+	}
+
+	for _, e := range contents.Images {
+		if e.Filename == "" {
+			continue
+		}
+
+		header, err := readPNGHeader(l.fsys, e.Filename)
+		if errors.Is(err, fs.ErrNotExist) {
+			l.report(LintSeverityError, "Contents.json", "references %s that doesn't exist", e.Filename)
+			continue
+		}
+		if errors.Is(err, errNotPNG) {
+			l.report(LintSeverityError, e.Filename, "is not a PNG")
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if w, ok := iosIconPixels(e.Size, e.Scale); ok && (header.width != w || header.height != w) {
+			l.report(LintSeverityError, e.Filename, "is %dx%d, expected %dx%d for %s@%s", header.width, header.height, w, w, e.Size, e.Scale)
+		}
+
+		// the dark appearance can be transparent, see [iosDarkAppIconDpi]
+		if (e.Idiom == "ios-marketing" || e.Idiom == "universal") && len(e.Appearances) == 0 && header.hasAlpha() {
+			l.report(LintSeverityError, e.Filename, "has an alpha channel, App Store Connect rejects the marketing icon with alpha")
+		}
+	}
+	return nil
+}
+
+// e.g: "iphone 60x60@2x" or "universal 1024x1024 dark"
+func iosDpiDescription(dpi iosAppIconDpiAsset) string {
+	description := dpi.Idiom + " " + dpi.SizeName
+	if dpi.Scale != "" {
+		description += "@" + dpi.Scale
+	}
+	for _, a := range dpi.Appearances {
+		description += " " + a.Value
+	}
+	return description
+}
+
+// The pixels of an iOS icon from its size in points (e.g: "83.5x83.5") and scale (e.g: "2x"). No scale is 1x,
+// as in the single size layout
+func iosIconPixels(size, scale string) (int, bool) {
+	if scale == "" {
+		scale = "1x"
+	}
+	points, _, _ := strings.Cut(size, "x")
+	pt, err := strconv.ParseFloat(points, 64)
+	if err != nil {
+		return 0, false
+	}
+	s, err := strconv.ParseFloat(strings.TrimSuffix(scale, "x"), 64)
+	if err != nil {
+		return 0, false
+	}
+	return int(pt * s), true
+}
+
+const (
+	pngColorTypeGrayAlpha = 4
+	pngColorTypeRGBA      = 6
+)
+
+type pngHeader struct {
+	width, height       int
+	bitDepth, colorType int
+	// A transparency chunk gives alpha to the color types without an alpha channel
+	hasTRNS  bool
+	fileSize int64
+}
+
+func (h pngHeader) hasAlpha() bool {
+	return h.colorType == pngColorTypeGrayAlpha || h.colorType == pngColorTypeRGBA || h.hasTRNS
+}
+
+func (h pngHeader) bitsPerPixel() int {
+	channels := map[int]int{0: 1, 2: 3, 3: 1, 4: 2, 6: 4}[h.colorType]
+	return h.bitDepth * channels
+}
+
+// Reads the IHDR chunk and looks for the tRNS chunk before the image data, without decoding the image
+func readPNGHeader(fsys fs.FS, name string) (pngHeader, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return pngHeader{}, err
+	}
+	if !bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")) {
+		return pngHeader{}, errNotPNG
+	}
+
+	header := pngHeader{fileSize: int64(len(data))}
+	r := bytes.NewReader(data[8:])
+	for {
+		var chunk struct {
+			Length uint32
+			Type   [4]byte
+		}
+		err := binary.Read(r, binary.BigEndian, &chunk)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return pngHeader{}, errNotPNG
+		}
+
+		body := make([]byte, chunk.Length)
+		_, err = io.ReadFull(r, body)
+		if err != nil {
+			return pngHeader{}, errNotPNG
+		}
+		r.Seek(4, io.SeekCurrent) // crc
+
+		switch string(chunk.Type[:]) {
+		case "IHDR":
+			if len(body) < 10 {
+				return pngHeader{}, errNotPNG
+			}
+			header.width = int(binary.BigEndian.Uint32(body[0:4]))
+			header.height = int(binary.BigEndian.Uint32(body[4:8]))
+			header.bitDepth = int(body[8])
+			header.colorType = int(body[9])
+		case "tRNS":
+			header.hasTRNS = true
+		case "IDAT":
+			return header, nil
+		}
+	}
+	return header, nil
+}
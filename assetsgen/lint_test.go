@@ -0,0 +1,85 @@
+package assetsgen
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLintNotificationIcons(t *testing.T) {
+	res := t.TempDir()
+	files := map[string][]byte{}
+	for _, a := range androidNotificationIconDpis(string(AndroidFolderDrawable)) {
+		w, h := a.CalcSize(0, 0)
+		// an opaque colored icon, android shows a white square
+		files[fmt.Sprint(a.DirName(), "/ic_notification.png")] = pngBytes(t, solidNRGBA(w, h, red))
+		// white on transparent
+		icon := solidNRGBA(w, h, color.NRGBA{})
+		fillRect(icon, image.Rect(w/4, h/4, w*3/4, h*3/4), white)
+		files[fmt.Sprint(a.DirName(), "/ic_stat_ok.png")] = pngBytes(t, icon)
+	}
+	writeFiles(t, res, files)
+
+	type issue struct {
+		severity LintSeverity
+		path     string
+		message  string
+	}
+	var notificationErrors []issue
+	for _, a := range androidNotificationIconDpis(string(AndroidFolderDrawable)) {
+		p := filepath.Join(res, a.DirName(), "ic_notification.png")
+		w, h := a.CalcSize(0, 0)
+		notificationErrors = append(notificationErrors,
+			issue{LintSeverityError, p, fmt.Sprintf("has %d non white pixels, the notification icons should be white on transparent", w*h)},
+			issue{LintSeverityError, p, "is fully opaque, it's shown as a white square"},
+		)
+	}
+	// the issues are sorted by path
+	slices.SortStableFunc(notificationErrors, func(a, b issue) int { return strings.Compare(a.path, b.path) })
+
+	tests := []struct {
+		name              string
+		notificationIcons string
+		want              []issue
+	}{
+		{
+			// only the ic_stat_ icons are notification icons
+			name: "default pattern",
+		},
+		{
+			name:              "custom pattern",
+			notificationIcons: "*/ic_notification.png",
+			want:              notificationErrors,
+		},
+		{
+			name:              "custom pattern with no match",
+			notificationIcons: "*/ic_notif.png",
+			want:              []issue{{LintSeverityWarning, filepath.Join(res, "*/ic_notif.png"), "no notification icons found"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues, err := Lint(LintDirs{AndroidRes: res, NotificationIcons: tt.notificationIcons})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []issue
+			for _, i := range issues {
+				got = append(got, issue{i.Severity, i.Path, i.Message})
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Lint() =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+
+	_, err := Lint(LintDirs{AndroidRes: res, NotificationIcons: "*/ic_[.png"})
+	if err == nil {
+		t.Error("a bad pattern is not an error")
+	}
+}
@@ -0,0 +1,241 @@
+package assetsgen
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Where the generators write the files to. The names are slash separated and relative to the root of the output,
+// e.g: android/res/mipmap-hdpi/ic_launcher.png, the folders are created as needed.
+// The file is complete once the writer is closed. The generators write from many goroutines at the same time
+type OutputSink interface {
+	Create(name string) (io.WriteCloser, error)
+}
+
+// Writes the files in a folder of the file system
+type DirSink struct {
+	root *os.Root
+}
+
+// Creates the folder if it doesn't exist and opens it. Empty falls back to [RootFolderName] in the working directory.
+// Close it when done
+func NewDirSink(dir string) (*DirSink, error) {
+	root, err := OpenOutputDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &DirSink{root: root}, nil
+}
+
+func (s *DirSink) Create(name string) (io.WriteCloser, error) {
+	dir := ""
+	for _, subdir := range splitPath(filepath.FromSlash(path.Dir(name))) {
+		dir = filepath.Join(dir, subdir)
+		err := s.root.Mkdir(dir, os.ModePerm)
+		if err != nil && !os.IsExist(err) {
+			return nil, err
+		}
+	}
+	return s.root.Create(filepath.FromSlash(name))
+}
+
+func (s *DirSink) Close() error {
+	return s.root.Close()
+}
+
+// Keeps the files in memory, e.g: for the tests or to serve them without touching the disk
+type MemorySink struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+func NewMemorySink() *MemorySink {
+	return &MemorySink{files: map[string][]byte{}}
+}
+
+func (s *MemorySink) Create(name string) (io.WriteCloser, error) {
+	return &bufferedFile{onClose: func(data []byte) error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.files[path.Clean(name)] = data
+		return nil
+	}}, nil
+}
+
+// The content of the file, false if it wasn't written
+func (s *MemorySink) File(name string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.files[path.Clean(name)]
+	return data, ok
+}
+
+// A copy of the written files by their names
+func (s *MemorySink) Files() map[string][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return maps.Clone(s.files)
+}
+
+// A read only snapshot of the written files, e.g: to render a preview of them with [RenderPreview]
+func (s *MemorySink) FS() fs.FS {
+	return memoryFS(s.Files())
+}
+
+// The folders are implied by the names of the files
+type memoryFS map[string][]byte
+
+func (m memoryFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if data, ok := m[name]; ok {
+		return &memoryFile{Reader: bytes.NewReader(data), info: memoryFileInfo{name: path.Base(name), size: int64(len(data))}}, nil
+	}
+
+	prefix := name + "/"
+	if name == "." {
+		prefix = ""
+	}
+	children := map[string]bool{}
+	for fileName := range m {
+		rest, ok := strings.CutPrefix(fileName, prefix)
+		if !ok {
+			continue
+		}
+		child, _, isDir := strings.Cut(rest, "/")
+		children[child] = children[child] || isDir
+	}
+	if len(children) == 0 && name != "." {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	entries := make([]fs.DirEntry, 0, len(children))
+	for _, child := range slices.Sorted(maps.Keys(children)) {
+		info := memoryFileInfo{name: child, dir: children[child]}
+		if !info.dir {
+			info.size = int64(len(m[path.Join(name, child)]))
+		}
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+	return &memoryDir{info: memoryFileInfo{name: path.Base(name), dir: true}, entries: entries}, nil
+}
+
+type memoryFileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (i memoryFileInfo) Name() string       { return i.name }
+func (i memoryFileInfo) Size() int64        { return i.size }
+func (i memoryFileInfo) ModTime() time.Time { return time.Time{} }
+func (i memoryFileInfo) IsDir() bool        { return i.dir }
+func (i memoryFileInfo) Sys() any           { return nil }
+
+func (i memoryFileInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+type memoryFile struct {
+	*bytes.Reader
+	info memoryFileInfo
+}
+
+func (f *memoryFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *memoryFile) Close() error               { return nil }
+
+type memoryDir struct {
+	info    memoryFileInfo
+	entries []fs.DirEntry
+}
+
+func (d *memoryDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *memoryDir) Close() error               { return nil }
+
+func (d *memoryDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+
+func (d *memoryDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(d.entries))
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
+
+// Streams the files into a zip archive. Every file is added once it is closed, so only the files being written
+// are kept in memory. Close it to write the end of the archive, the underlying writer is not closed
+type ZipSink struct {
+	mu sync.Mutex
+	zw *zip.Writer
+}
+
+func NewZipSink(w io.Writer) *ZipSink {
+	return &ZipSink{zw: zip.NewWriter(w)}
+}
+
+func (s *ZipSink) Create(name string) (io.WriteCloser, error) {
+	return &bufferedFile{onClose: func(data []byte) error {
+		// the zip writer takes one file at a time
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		f, err := s.zw.CreateHeader(&zip.FileHeader{Name: path.Clean(name), Method: zip.Deflate})
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(data)
+		return err
+	}}, nil
+}
+
+func (s *ZipSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.zw.Close()
+}
+
+type bufferedFile struct {
+	bytes.Buffer
+	onClose func(data []byte) error
+}
+
+func (f *bufferedFile) Close() error {
+	return f.onClose(f.Bytes())
+}
+
+func writeFile(output OutputSink, name string, data []byte) error {
+	f, err := output.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,91 @@
+package assetsgen
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"maps"
+	"testing"
+	"testing/fstest"
+)
+
+var sinkTestFiles = map[string]string{
+	"android/res/mipmap-hdpi/ic_launcher.png":              "hdpi",
+	"android/res/mipmap-xhdpi/ic_launcher.png":             "xhdpi",
+	"android/res/mipmap-anydpi-v26/ic_launcher.xml":        "<adaptive-icon />",
+	"ios/Assets.xcassets/AppIcon.appiconset/Contents.json": "{}",
+	"empty.txt": "",
+}
+
+func writeSinkTestFiles(t *testing.T, sink OutputSink) {
+	t.Helper()
+	for name, content := range sinkTestFiles {
+		w, err := sink.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// in two writes, the file is complete once closed
+		half := len(content) / 2
+		io.WriteString(w, content[:half])
+		io.WriteString(w, content[half:])
+		err = w.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMemorySinkFS(t *testing.T) {
+	sink := NewMemorySink()
+	writeSinkTestFiles(t, sink)
+
+	err := fstest.TestFS(sink.FS(), "android/res/mipmap-hdpi/ic_launcher.png", "ios/Assets.xcassets/AppIcon.appiconset/Contents.json", "empty.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]string{}
+	for name, data := range sink.Files() {
+		got[name] = string(data)
+	}
+	if !maps.Equal(got, sinkTestFiles) {
+		t.Errorf("Files() = %v, want %v", got, sinkTestFiles)
+	}
+}
+
+func TestZipSinkRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewZipSink(&buf)
+	writeSinkTestFiles(t, sink)
+	err := sink.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", f.Name, err)
+		}
+		got[f.Name] = string(data)
+	}
+	if !maps.Equal(got, sinkTestFiles) {
+		t.Errorf("the zip has %v, want %v", got, sinkTestFiles)
+	}
+
+	// the archive is a valid file system too, with the folders implied by the names
+	err = fstest.TestFS(zr, "android/res/mipmap-xhdpi/ic_launcher.png", "empty.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
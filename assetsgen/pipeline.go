@@ -0,0 +1,207 @@
+package assetsgen
+
+import (
+	"image"
+	"image/color"
+)
+
+// A chain of the generators' steps on an in-memory image, for embedding assetsgen in other tools.
+// Every step returns a new pipeline and leaves the previous one as is, so a pipeline can be branched.
+// Nothing is written to the disk, see [Pipeline.Image] and [Pipeline.ForAssets] for the results.
+//
+//	logo := assetsgen.NewPipeline(img).TrimWhiteSpace().SquareImageWithEmptyPixels(32)
+//	icons, err := logo.Background(assetsgen.NewSolidColorBackground(c)).StackWithNoAlpha(0.5, logo).ForAssets(targets)
+type Pipeline struct {
+	info imageInfo
+	err  error
+}
+
+type AssetImage struct {
+	Asset Asset
+	Image image.Image
+}
+
+// A fixed size [Asset], e.g: NewAsset("AppIcon@2x", "", 120, 120)
+func NewAsset(name, dirName string, w, h int) Asset {
+	return fixedAsset{name: name, dirName: dirName, w: w, h: h}
+}
+
+type fixedAsset struct {
+	name    string
+	dirName string
+	w, h    int
+}
+
+func (a fixedAsset) Name() string {
+	return a.name
+}
+
+func (a fixedAsset) CalcSize(_, _ int) (int, int) {
+	return a.w, a.h
+}
+
+func (a fixedAsset) DirName() string {
+	return a.dirName
+}
+
+// The image is not modified. Resizes with [DefaultResizeOptions] in linear light like the icons, see [Pipeline.SetResizeOptions]
+func NewPipeline(img image.Image) *Pipeline {
+	return &Pipeline{
+		info: imageInfo{
+			img:           img,
+			resizeOptions: DefaultResizeOptions().withDefaultSpace(ResizeSpaceLinear),
+		},
+	}
+}
+
+// Runs the step on a copy of the pipeline, the steps replace the image instead of modifying it
+func (p *Pipeline) step(fn func(info *imageInfo) *imageInfo) *Pipeline {
+	if p.err != nil {
+		return p
+	}
+	info := p.info.ShallowCopy()
+	return &Pipeline{info: *fn(info)}
+}
+
+// The result of the steps so far, nil if one of them failed, see [Pipeline.Err]
+func (p *Pipeline) Image() image.Image {
+	if p.err != nil {
+		return nil
+	}
+	return p.info.img
+}
+
+// The error of the first step that failed, the steps after it are skipped
+func (p *Pipeline) Err() error {
+	return p.err
+}
+
+func (p *Pipeline) SetResizeOptions(options ResizeOptions) *Pipeline {
+	return p.step(func(info *imageInfo) *imageInfo {
+		return info.SetResizeOptions(options.withDefaultSpace(ResizeSpaceLinear))
+	})
+}
+
+func (p *Pipeline) Resize(w, h int) *Pipeline {
+	return p.step(func(info *imageInfo) *imageInfo { return info.Resize(w, h) })
+}
+
+// Crops out the transparent pixels around the image
+func (p *Pipeline) TrimWhiteSpace() *Pipeline {
+	return p.step(func(info *imageInfo) *imageInfo { return info.TrimWhiteSpace() })
+}
+
+// Crops out the background around the image, see [TrimOptions]
+func (p *Pipeline) Trim(options TrimOptions) *Pipeline {
+	return p.step(func(info *imageInfo) *imageInfo { return info.Trim(options) })
+}
+
+// Makes the background color around the logo transparent, see [TrimOptions.KeyOut]
+func (p *Pipeline) KeyOutBackground(options TrimOptions) *Pipeline {
+	return p.step(func(info *imageInfo) *imageInfo { return info.KeyOutBackground(options) })
+}
+
+// Centers the image in a square transparent canvas, then adds [padding] pixels on all the sides
+func (p *Pipeline) SquareImageWithEmptyPixels(padding int) *Pipeline {
+	return p.step(func(info *imageInfo) *imageInfo { return info.SquareImageWithEmptyPixels(padding) })
+}
+
+// Same as [Pipeline.SquareImageWithEmptyPixels] but moves the logo toward its visual center, [amount] between [0..1]
+func (p *Pipeline) SquareImageWithOpticalCenter(padding int, amount float64) *Pipeline {
+	return p.step(func(info *imageInfo) *imageInfo { return info.SquareImageWithOpticalCenter(padding, amount) })
+}
+
+// Adds [padding] transparent pixels on all the sides
+func (p *Pipeline) Padding(padding int) *Pipeline {
+	return p.step(func(info *imageInfo) *imageInfo { return info.Padding(padding) })
+}
+
+// Centers the image in a transparent canvas of w,h
+func (p *Pipeline) CenterInCanvas(w, h int) *Pipeline {
+	return p.step(func(info *imageInfo) *imageInfo { return info.CenterInCanvas(w, h) })
+}
+
+func (p *Pipeline) CropToSquare() *Pipeline {
+	return p.step(func(info *imageInfo) *imageInfo { return info.CropToSquare() })
+}
+
+// [percentRadius] between [0..1] as percentage of the radius, 1 is a circle and 0 does nothing
+func (p *Pipeline) ClipRRect(percentRadius float64) *Pipeline {
+	return p.step(func(info *imageInfo) *imageInfo { return info.ClipRRect(percentRadius) })
+}
+
+// Makes the pixels more transparent than [threshold] fully transparent and the others opaque, the fully transparent
+// and fully opaque pixels are kept as is
+func (p *Pipeline) RemoveAlphaOnThreshold(threshold float64) *Pipeline {
+	return p.step(func(info *imageInfo) *imageInfo { return info.RemoveAlphaOnThreshold(threshold) })
+}
+
+// Paints every pixel that is not fully transparent with the color, e.g: white for the notification icons
+func (p *Pipeline) ConvertNoneOpaqueToColor(c color.Color) *Pipeline {
+	return p.step(func(info *imageInfo) *imageInfo { return info.ConvertNoneOpaqueToColor(c) })
+}
+
+// Replaces the image with the background generated at its size
+func (p *Pipeline) Background(bg BackgroundIcon) *Pipeline {
+	if p.err != nil {
+		return p
+	}
+	info, err := bg.generateImgInfo(&p.info)
+	if err != nil {
+		return &Pipeline{err: err}
+	}
+	return &Pipeline{info: *info}
+}
+
+// Lays the layers out on top of the image, the last one on top. All the images should be the same size.
+// Every pixel is taken from the top most layer that is not fully transparent there, see [Pipeline.StackOver] to blend them
+func (p *Pipeline) Stack(layers ...*Pipeline) *Pipeline {
+	return p.stack(layers, func(info *imageInfo, infos []*imageInfo) *imageInfo { return info.Stack(infos...) })
+}
+
+// Same as [Pipeline.Stack] but the translucent pixels of the layers are blended over the pixels below them
+func (p *Pipeline) StackOver(layers ...*Pipeline) *Pipeline {
+	return p.stack(layers, func(info *imageInfo, infos []*imageInfo) *imageInfo { return info.StackOver(infos...) })
+}
+
+// Same as [Pipeline.Stack] but the pixels of the layers more transparent than [threshold] are dropped and the result
+// is fully opaque, for the targets that don't allow transparency
+func (p *Pipeline) StackWithNoAlpha(threshold float64, layers ...*Pipeline) *Pipeline {
+	return p.stack(layers, func(info *imageInfo, infos []*imageInfo) *imageInfo {
+		return info.StackWithNoAlpha(threshold, infos...)
+	})
+}
+
+// Same as [Pipeline.StackWithNoAlpha] but the alpha of the image (the background) is kept
+func (p *Pipeline) StackWithBgAlpha(threshold float64, layers ...*Pipeline) *Pipeline {
+	return p.stack(layers, func(info *imageInfo, infos []*imageInfo) *imageInfo {
+		return info.StackWithBgAlpha(threshold, infos...)
+	})
+}
+
+func (p *Pipeline) stack(layers []*Pipeline, fn func(info *imageInfo, infos []*imageInfo) *imageInfo) *Pipeline {
+	infos := make([]*imageInfo, len(layers))
+	for i, layer := range layers {
+		if layer.err != nil {
+			return &Pipeline{err: layer.err}
+		}
+		infos[i] = &layer.info
+	}
+	return p.step(func(info *imageInfo) *imageInfo { return fn(info, infos) })
+}
+
+// Resizes the image to every asset, sharing the work between the assets of close sizes. The results are in the order of the assets
+func (p *Pipeline) ForAssets(assets []Asset) ([]AssetImage, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	s := p.info.SplitPerAsset(assets)
+	s.ResizeForAssets()
+
+	out := make([]AssetImage, len(assets))
+	for i, v := range *s {
+		out[i] = AssetImage{Asset: v.asset, Image: v.img}
+	}
+	return out, nil
+}
@@ -0,0 +1,99 @@
+package assetsgen
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"io/fs"
+	"path/filepath"
+	"testing"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+// A translucent square in the middle of a transparent image
+func pipelineTestNRGBA() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+	for y := 4; y < 12; y++ {
+		for x := 4; x < 12; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: 51, G: 102, B: 204, A: 200})
+		}
+	}
+	return img
+}
+
+func TestPipelineBranchesLeaveTheParent(t *testing.T) {
+	src := pipelineTestNRGBA()
+	srcPix := bytes.Clone(src.Pix)
+
+	parent := NewPipeline(src).Padding(2)
+	parentImg := asNRGBA(parent.Image())
+	parentPix := bytes.Clone(parentImg.Pix)
+	layer := NewPipeline(src).Padding(2).ConvertNoneOpaqueToColor(color.White)
+	layerPix := bytes.Clone(asNRGBA(layer.Image()).Pix)
+
+	branches := map[string]*Pipeline{
+		"ClipRRect":                parent.ClipRRect(1),
+		"RemoveAlphaOnThreshold":   parent.RemoveAlphaOnThreshold(0.5),
+		"ConvertNoneOpaqueToColor": parent.ConvertNoneOpaqueToColor(color.Black),
+		"Resize":                   parent.Resize(8, 8),
+		"TrimWhiteSpace":           parent.TrimWhiteSpace(),
+		"Background":               parent.Background(NewSolidColorBackground(colorful.Color{R: 1})),
+		"Stack":                    parent.Stack(layer),
+		"StackOver":                parent.StackOver(layer),
+		"StackWithNoAlpha":         parent.StackWithNoAlpha(0.5, layer),
+	}
+
+	for name, branch := range branches {
+		if branch == parent || branch.Err() != nil || branch.Image() == nil {
+			t.Errorf("%s: not a new pipeline with an image, err %v", name, branch.Err())
+		}
+	}
+	if parent.Image() != image.Image(parentImg) || !bytes.Equal(parentImg.Pix, parentPix) {
+		t.Error("the branches changed the parent")
+	}
+	if !bytes.Equal(asNRGBA(layer.Image()).Pix, layerPix) {
+		t.Error("the stacks changed the layer")
+	}
+	if !bytes.Equal(src.Pix, srcPix) {
+		t.Error("the pipeline changed the source image")
+	}
+
+	// the branches don't share their results either
+	clipped := asNRGBA(branches["ClipRRect"].Image())
+	if black := asNRGBA(branches["ConvertNoneOpaqueToColor"].Image()); &clipped.Pix[0] == &black.Pix[0] {
+		t.Error("two branches share their pixels")
+	}
+}
+
+func TestPipelineErr(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.png")
+	logo := NewPipeline(pipelineTestNRGBA())
+
+	failed := logo.Background(NewImageBackground(missing))
+	if !errors.Is(failed.Err(), fs.ErrNotExist) {
+		t.Fatalf("Err() = %v, want %v", failed.Err(), fs.ErrNotExist)
+	}
+
+	// the steps after the failed one are skipped, and the stacks on top of it fail too
+	after := failed.ClipRRect(0.5).Resize(8, 8)
+	stacked := logo.Stack(failed)
+	for name, p := range map[string]*Pipeline{"after": after, "stacked": stacked} {
+		if !errors.Is(p.Err(), fs.ErrNotExist) {
+			t.Errorf("%s: Err() = %v, want %v", name, p.Err(), fs.ErrNotExist)
+		}
+		if p.Image() != nil {
+			t.Errorf("%s: Image() is not nil", name)
+		}
+		_, err := p.ForAssets([]Asset{NewAsset("icon", "", 4, 4)})
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("%s: ForAssets() err = %v, want %v", name, err, fs.ErrNotExist)
+		}
+	}
+
+	// the pipeline before the failed step still works
+	if logo.Err() != nil || logo.Image() == nil {
+		t.Errorf("the failed branch changed its parent, err %v", logo.Err())
+	}
+}
@@ -0,0 +1,129 @@
+package assetsgen
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"runtime"
+	"sync"
+)
+
+// Returns the image as *image.NRGBA with its bounds starting at (0,0).
+// The image is returned as is if it's already one, so the result must be treated as read only.
+func asNRGBA(img image.Image) *image.NRGBA {
+	if nrgba, ok := img.(*image.NRGBA); ok && nrgba.Rect.Min == (image.Point{}) {
+		return nrgba
+	}
+
+	bounds := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Rect, img, bounds.Min, draw.Src)
+	return dst
+}
+
+// A copy of the image as *image.NRGBA with its bounds starting at (0,0), that can be changed without changing img
+func cloneNRGBA(img image.Image) *image.NRGBA {
+	src := asNRGBA(img)
+	if src != img {
+		// already a new image
+		return src
+	}
+
+	dst := image.NewNRGBA(src.Rect)
+	for y := range src.Rect.Dy() {
+		copy(dst.Pix[y*dst.Stride:y*dst.Stride+src.Rect.Dx()*4], src.Pix[y*src.Stride:])
+	}
+	return dst
+}
+
+// Splits the rows [0..h) between the CPUs and calls fn for each row, returns after all the rows are done
+func parallelRows(h int, fn func(y int)) {
+	workers := min(runtime.GOMAXPROCS(0), h)
+	if workers <= 1 {
+		for y := range h {
+			fn(y)
+		}
+		return
+	}
+
+	rowsPerWorker := (h + workers - 1) / workers
+
+	wg := sync.WaitGroup{}
+	for start := 0; start < h; start += rowsPerWorker {
+		end := min(start+rowsPerWorker, h)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for y := start; y < end; y++ {
+				fn(y)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Calls fn for every pixel of src with the pixel of the same position in a new image.
+// [srcPx] and [dstPx] are the 4 bytes R, G, B, A of the pixel, the color is not premultiplied by the alpha.
+// dst starts as fully transparent
+func mapNRGBA(src *image.NRGBA, fn func(x, y int, srcPx, dstPx []uint8)) *image.NRGBA {
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+
+	parallelRows(h, func(y int) {
+		srcRow := src.Pix[y*src.Stride : y*src.Stride+w*4]
+		dstRow := dst.Pix[y*dst.Stride : y*dst.Stride+w*4]
+		for x := range w {
+			i := x * 4
+			fn(x, y, srcRow[i:i+4:i+4], dstRow[i:i+4:i+4])
+		}
+	})
+
+	return dst
+}
+
+func (imgInfo *imageInfo) UpdatePixels(updater func(x, y int, c color.Color) color.Color) *imageInfo {
+	return imgInfo.UpdateNRGBAPixels(func(x, y int, c color.NRGBA) color.NRGBA {
+		return color.NRGBAModel.Convert(updater(x, y, c)).(color.NRGBA)
+	})
+}
+
+// Same as [imageInfo.UpdatePixels] without converting every pixel to the color.Color interface
+func (imgInfo *imageInfo) UpdateNRGBAPixels(updater func(x, y int, c color.NRGBA) color.NRGBA) *imageInfo {
+	imgInfo.img = mapNRGBA(asNRGBA(imgInfo.img), func(x, y int, srcPx, dstPx []uint8) {
+		c := updater(x, y, color.NRGBA{R: srcPx[0], G: srcPx[1], B: srcPx[2], A: srcPx[3]})
+		dstPx[0], dstPx[1], dstPx[2], dstPx[3] = c.R, c.G, c.B, c.A
+	})
+	return imgInfo
+}
+
+// Keeps the pixels where keep returns true, the others will be fully transparent
+func (imgInfo *imageInfo) clipPixels(keep func(x, y int) bool) *imageInfo {
+	imgInfo.img = mapNRGBA(asNRGBA(imgInfo.img), func(x, y int, srcPx, dstPx []uint8) {
+		if keep(x, y) {
+			copy(dstPx, srcPx)
+		}
+	})
+	return imgInfo
+}
+
+// Copies src into a new transparent w,h image with the (0,0) of src at [at] of the new image, the pixels outside it are dropped.
+// Unlike drawing into an image.RGBA the colors of the translucent pixels are kept as they are,
+// the 8 bits premultiplied form rounds the colors of the faint edges to black.
+func placeNRGBA(img image.Image, w, h int, at image.Point) *image.NRGBA {
+	src := asNRGBA(img)
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+
+	r := src.Rect.Add(at).Intersect(dst.Rect)
+	if r.Empty() {
+		return dst
+	}
+
+	rowLen := r.Dx() * 4
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		srcStart := (y-at.Y)*src.Stride + (r.Min.X-at.X)*4
+		dstStart := y*dst.Stride + r.Min.X*4
+		copy(dst.Pix[dstStart:dstStart+rowLen], src.Pix[srcStart:srcStart+rowLen])
+	}
+	return dst
+}
@@ -0,0 +1,151 @@
+package assetsgen
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"io"
+)
+
+// Encodes the image as 8 bits per channel RGBA PNG (32-bit) even if it's opaque.
+// The standard encoder drops the alpha channel of the opaque images, but Google Play asks for 32-bit PNG
+func encodePNG32(w io.Writer, img image.Image) error {
+	src := asNRGBA(img)
+	width, height := src.Rect.Dx(), src.Rect.Dy()
+
+	bw := bufio.NewWriter(w)
+	_, err := bw.WriteString("\x89PNG\r\n\x1a\n")
+	if err != nil {
+		return err
+	}
+
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:4], uint32(width))
+	binary.BigEndian.PutUint32(ihdr[4:8], uint32(height))
+	ihdr[8] = 8 // bit depth
+	ihdr[9] = 6 // color type: truecolor with alpha
+	err = writePNGChunk(bw, "IHDR", ihdr)
+	if err != nil {
+		return err
+	}
+
+	idat := bytes.Buffer{}
+	zw, err := zlib.NewWriterLevel(&idat, zlib.BestCompression)
+	if err != nil {
+		return err
+	}
+
+	rowLen := width * 4
+	prev := make([]byte, rowLen)
+	filtered := make([][]byte, 5)
+	for i := range filtered {
+		filtered[i] = make([]byte, rowLen+1)
+		filtered[i][0] = byte(i)
+	}
+
+	for y := range height {
+		row := src.Pix[y*src.Stride : y*src.Stride+rowLen]
+		_, err = zw.Write(filterPNGRow(row, prev, filtered))
+		if err != nil {
+			return err
+		}
+		prev = row
+	}
+
+	err = zw.Close()
+	if err != nil {
+		return err
+	}
+
+	err = writePNGChunk(bw, "IDAT", idat.Bytes())
+	if err != nil {
+		return err
+	}
+	err = writePNGChunk(bw, "IEND", nil)
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// Applies the 5 PNG filters to the row and returns the one with the smallest sum of the absolute differences,
+// the same heuristic the standard encoder uses
+func filterPNGRow(row, prev []byte, filtered [][]byte) []byte {
+	const bpp = 4
+
+	best, bestSum := filtered[0], -1
+	for f, out := range filtered {
+		cur := out[1:]
+		for i, v := range row {
+			var left, up, upLeft byte
+			if i >= bpp {
+				left, upLeft = row[i-bpp], prev[i-bpp]
+			}
+			up = prev[i]
+
+			switch f {
+			case 0:
+				cur[i] = v
+			case 1:
+				cur[i] = v - left
+			case 2:
+				cur[i] = v - up
+			case 3:
+				cur[i] = v - byte((int(left)+int(up))/2)
+			case 4:
+				cur[i] = v - paeth(left, up, upLeft)
+			}
+		}
+
+		sum := 0
+		for _, v := range cur {
+			sum += int(min(v, -v))
+		}
+		if bestSum < 0 || sum < bestSum {
+			best, bestSum = out, sum
+		}
+	}
+	return best
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	if pa <= pb && pa <= pc {
+		return a
+	}
+	if pb <= pc {
+		return b
+	}
+	return c
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func writePNGChunk(w io.Writer, chunkType string, data []byte) error {
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header[:4], uint32(len(data)))
+	copy(header[4:], chunkType)
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+
+	_, err := w.Write(header)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	if err != nil {
+		return err
+	}
+	return binary.Write(w, binary.BigEndian, crc.Sum32())
+}
@@ -0,0 +1,564 @@
+package assetsgen
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/lucasb-eyer/go-colorful"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+var (
+	ErrInvalidPreviewFormat = errors.New("invalid preview format")
+	ErrNothingToPreview     = errors.New("did not find any generated icons to preview")
+)
+
+type PreviewFormat string
+
+const (
+	PreviewFormatPng  PreviewFormat = "png"
+	PreviewFormatHtml PreviewFormat = "html"
+)
+
+var PreviewFormats = []PreviewFormat{PreviewFormatPng, PreviewFormatHtml}
+
+func ParsePreviewFormat(s string) (PreviewFormat, error) {
+	f := PreviewFormat(s)
+	if !slices.Contains(PreviewFormats, f) {
+		return "", ErrInvalidPreviewFormat
+	}
+	return f, nil
+}
+
+type PreviewOptions struct {
+	// Empty falls back to png
+	Format PreviewFormat
+
+	// The size of every icon tile in pixels. Zero falls back to 192
+	TileSize int
+
+	// The name of the preview file without the extension, saved in the root of [RootFolderName]. Empty falls back to "preview"
+	OutputFileName string
+}
+
+const (
+	defaultPreviewTileSize = 192
+
+	// The iOS icons are masked with a continuous corner of about 22.37% of the size
+	iosCornerRatio = 2 * 0.2237
+	// Google Play masks the store listing icon with a corner radius of 20% of the size
+	googlePlayCornerRatio = 2 * 0.2
+)
+
+var androidDensities = []string{"xxxhdpi", "xxhdpi", "xhdpi", "hdpi", "mdpi", "ldpi"}
+
+var (
+	previewSheetColor   = color.NRGBA{R: 0xF8, G: 0xF9, B: 0xFA, A: 0xFF}
+	previewTextColor    = color.NRGBA{R: 0x20, G: 0x21, B: 0x24, A: 0xFF}
+	statusBarLightColor = color.NRGBA{R: 0xF1, G: 0xF3, B: 0xF4, A: 0xFF}
+	statusBarLightIcon  = color.NRGBA{R: 0x20, G: 0x21, B: 0x24, A: 0xFF}
+	statusBarDarkColor  = color.NRGBA{R: 0x20, G: 0x21, B: 0x24, A: 0xFF}
+	statusBarDarkIcon   = color.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+)
+
+// The launcher masks, the safe zone isn't a mask
+var previewAdaptiveIconShapes = []AdaptiveIconShape{
+	AdaptiveIconShapeCircle,
+	AdaptiveIconShapeSquircle,
+	AdaptiveIconShapeRoundedSquare,
+	AdaptiveIconShapeTeardrop,
+}
+
+type previewTile struct {
+	label string
+	img   *image.NRGBA
+}
+
+type previewGroup struct {
+	title string
+	tiles []previewTile
+}
+
+// Renders a contact sheet of the icons in [RootFolderName] as they are shown on the devices and the stores:
+// the adaptive icons under the launcher masks, the legacy and round icons, the notification icons on light and
+// dark status bars, the Google Play logo and the iOS icons with the system corner radius.
+// Returns the path of the preview file
+func GeneratePreview(option PreviewOptions) (string, error) {
+	option = option.withDefaults()
+
+	rootDir, err := GetRootDir()
+	if err != nil {
+		return "", err
+	}
+	defer rootDir.Close()
+
+	// rendered first to not leave a broken preview file behind on errors
+	var buf bytes.Buffer
+	err = RenderPreview(&buf, rootDir.FS(), option)
+	if err != nil {
+		return "", err
+	}
+
+	filename := fmt.Sprint(option.OutputFileName, ".", option.Format)
+	f, err := rootDir.Create(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	_, err = buf.WriteTo(f)
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(RootFolderName, filename), nil
+}
+
+// Same as [GeneratePreview] for the icons in [fsys], laid out like [RootFolderName]. e.g: [MemorySink.FS].
+// [PreviewOptions.OutputFileName] is not used
+func RenderPreview(w io.Writer, fsys fs.FS, option PreviewOptions) error {
+	option = option.withDefaults()
+
+	groups, err := collectPreviewGroups(fsys, option.TileSize)
+	if err != nil {
+		return err
+	}
+	if len(groups) == 0 {
+		return ErrNothingToPreview
+	}
+
+	switch option.Format {
+	case PreviewFormatHtml:
+		page, err := renderPreviewHtml(groups)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, page)
+		return err
+	default:
+		return png.Encode(w, renderPreviewSheet(groups, option.TileSize))
+	}
+}
+
+func (option PreviewOptions) withDefaults() PreviewOptions {
+	if option.Format == "" {
+		option.Format = PreviewFormatPng
+	}
+	if option.TileSize <= 0 {
+		option.TileSize = defaultPreviewTileSize
+	}
+	if option.OutputFileName == "" {
+		option.OutputFileName = "preview"
+	}
+	return option
+}
+
+func collectPreviewGroups(fsys fs.FS, tileSize int) ([]previewGroup, error) {
+	var groups []previewGroup
+
+	adaptive, err := adaptiveIconPreviewGroups(fsys, tileSize)
+	if err != nil {
+		return nil, err
+	}
+	groups = append(groups, adaptive...)
+
+	notification, err := notificationIconPreviewGroup(fsys, tileSize)
+	if err != nil {
+		return nil, err
+	}
+	groups = appendNotEmpty(groups, notification)
+
+	play, err := googlePlayLogoPreviewGroup(fsys, tileSize)
+	if err != nil {
+		return nil, err
+	}
+	groups = appendNotEmpty(groups, play)
+
+	ios, err := iosAppIconPreviewGroup(fsys, tileSize)
+	if err != nil {
+		return nil, err
+	}
+	groups = appendNotEmpty(groups, ios)
+
+	return groups, nil
+}
+
+func appendNotEmpty(groups []previewGroup, g previewGroup) []previewGroup {
+	if len(g.tiles) == 0 {
+		return groups
+	}
+	return append(groups, g)
+}
+
+var (
+	adaptiveIconLayerRegexp = regexp.MustCompile(`<(background|foreground|monochrome) android:drawable="@(\w+)/(\w+)"`)
+	androidColorRegexp      = regexp.MustCompile(`<color name="(\w+)">(#[0-9a-fA-F]{6,8})</color>`)
+)
+
+// One group per adaptive icon xml: the icon under every launcher mask, then the legacy and round icons of the same name
+func adaptiveIconPreviewGroups(fsys fs.FS, tileSize int) ([]previewGroup, error) {
+	xmlPaths, err := fs.Glob(fsys, "android/res/*-anydpi-v26/*.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []previewGroup
+	for _, xmlPath := range xmlPaths {
+		data, err := fs.ReadFile(fsys, xmlPath)
+		if err != nil {
+			return nil, err
+		}
+		name := strings.TrimSuffix(path.Base(xmlPath), ".xml")
+		group := previewGroup{title: fmt.Sprint("android ", name)}
+
+		var background, foreground image.Image
+		for _, m := range adaptiveIconLayerRegexp.FindAllStringSubmatch(string(data), -1) {
+			layer, resType, resName := m[1], m[2], m[3]
+			if layer == "monochrome" {
+				continue
+			}
+
+			var img image.Image
+			if resType == "color" {
+				c, err := findAndroidColor(fsys, resName)
+				if err != nil {
+					return nil, err
+				}
+				img = image.NewUniform(c)
+			} else {
+				img, err = openBestDensity(fsys, resName)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			if layer == "background" {
+				background = img
+			} else {
+				foreground = img
+			}
+		}
+
+		if foreground != nil {
+			layers := composeAdaptiveIconLayers(background, foreground, tileSize)
+			for _, shape := range previewAdaptiveIconShapes {
+				group.tiles = append(group.tiles, previewTile{
+					label: string(shape),
+					img:   maskAdaptiveIcon(layers, shape, tileSize),
+				})
+			}
+		}
+
+		for _, legacy := range []string{name, fmt.Sprint(name, "_round")} {
+			img, err := openBestDensity(fsys, legacy)
+			if err != nil {
+				return nil, err
+			}
+			if img != nil {
+				group.tiles = append(group.tiles, previewTile{label: legacy, img: resizePreview(img, tileSize)})
+			}
+		}
+
+		groups = appendNotEmpty(groups, group)
+	}
+	return groups, nil
+}
+
+// Finds the color resource in the values xml files, the missing color is transparent
+func findAndroidColor(fsys fs.FS, name string) (color.NRGBA, error) {
+	xmlPaths, err := fs.Glob(fsys, "android/res/values*/*.xml")
+	if err != nil {
+		return color.NRGBA{}, err
+	}
+	for _, xmlPath := range xmlPaths {
+		data, err := fs.ReadFile(fsys, xmlPath)
+		if err != nil {
+			return color.NRGBA{}, err
+		}
+		for _, m := range androidColorRegexp.FindAllStringSubmatch(string(data), -1) {
+			if m[1] == name {
+				return parseAndroidColorHex(m[2])
+			}
+		}
+	}
+	return color.NRGBA{}, nil
+}
+
+// Parses the android color resource format #AARRGGBB or #RRGGBB, see [androidColorHex]
+func parseAndroidColorHex(s string) (color.NRGBA, error) {
+	var alpha uint8 = 255
+	if len(s) == 9 {
+		var a uint
+		_, err := fmt.Sscanf(s[1:3], "%02x", &a)
+		if err != nil {
+			return color.NRGBA{}, err
+		}
+		alpha = uint8(a)
+		s = "#" + s[3:]
+	}
+	c, err := colorful.Hex(s)
+	if err != nil {
+		return color.NRGBA{}, err
+	}
+	return toNRGBA(c, float64(alpha)/255), nil
+}
+
+// Opens the png of the highest density among the mipmap and drawable folders, nil if there is none
+func openBestDensity(fsys fs.FS, name string) (image.Image, error) {
+	for _, density := range androidDensities {
+		for _, folder := range []AndroidFolderName{AndroidFolderMipmap, AndroidFolderDrawable} {
+			img, err := openPreviewImage(fsys, path.Join("android", "res", fmt.Sprint(folder, "-", density), fmt.Sprint(name, ".png")))
+			if err != nil || img != nil {
+				return img, err
+			}
+		}
+	}
+	return nil, nil
+}
+
+// Decodes the image, nil if the file doesn't exist
+func openPreviewImage(fsys fs.FS, name string) (image.Image, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+func resizePreview(img image.Image, size int) *image.NRGBA {
+	return asNRGBA(ResizeOptions{Filter: ResampleFilterLanczos, Space: ResizeSpaceLinear}.resize(img, size, size))
+}
+
+// Stacks the foreground on the background at the size of the whole 108dp layer, the tile shows the 72dp viewport of it
+func composeAdaptiveIconLayers(background, foreground image.Image, tileSize int) *image.NRGBA {
+	layerSize := tileSize * adaptiveIconLayerDp / adaptiveIconViewportDp
+	layers := image.NewNRGBA(image.Rect(0, 0, layerSize, layerSize))
+
+	if u, ok := background.(*image.Uniform); ok {
+		draw.Draw(layers, layers.Rect, u, image.Point{}, draw.Src)
+	} else if background != nil {
+		draw.Draw(layers, layers.Rect, resizePreview(background, layerSize), image.Point{}, draw.Src)
+	}
+	draw.Draw(layers, layers.Rect, resizePreview(foreground, layerSize), image.Point{}, draw.Over)
+
+	return layers
+}
+
+func maskAdaptiveIcon(layers *image.NRGBA, shape AdaptiveIconShape, tileSize int) *image.NRGBA {
+	offset := (layers.Rect.Dx() - tileSize) / 2
+	viewport := layers.SubImage(image.Rect(offset, offset, offset+tileSize, offset+tileSize))
+
+	dpPerPx := float64(adaptiveIconViewportDp) / float64(tileSize)
+	return maskPreview(viewport, tileSize, func(x, y float64) float64 {
+		return shape.gauge(x*dpPerPx, y*dpPerPx)
+	})
+}
+
+func maskRoundedSquare(img image.Image, tileSize int, cornerRatio float64) *image.NRGBA {
+	half := float64(tileSize) / 2
+	return maskPreview(resizePreview(img, tileSize), tileSize, func(x, y float64) float64 {
+		return roundedSquareGauge(x, y, half, cornerRatio)
+	})
+}
+
+// Keeps the pixels inside the shape given by its gauge in pixels relative to the center of the tile, see
+// [AdaptiveIconShape.gauge]. The edge is anti aliased over about a pixel
+func maskPreview(img image.Image, tileSize int, gauge func(x, y float64) float64) *image.NRGBA {
+	half := float64(tileSize) / 2
+	return mapNRGBA(asNRGBA(img), func(x, y int, srcPx, dstPx []uint8) {
+		g := gauge(float64(x)+0.5-half, float64(y)+0.5-half)
+		coverage := min(max((1-g)*half+0.5, 0), 1)
+		copy(dstPx, srcPx)
+		dstPx[3] = uint8(float64(srcPx[3])*coverage + 0.5)
+	})
+}
+
+// The ic_stat_ icons tinted by the system as it does on the light and dark status bars
+func notificationIconPreviewGroup(fsys fs.FS, tileSize int) (previewGroup, error) {
+	group := previewGroup{title: "android notification icons"}
+
+	pngPaths, err := fs.Glob(fsys, "android/res/*/ic_stat_*.png")
+	if err != nil {
+		return group, err
+	}
+
+	var names []string
+	for _, p := range pngPaths {
+		names = append(names, strings.TrimSuffix(path.Base(p), ".png"))
+	}
+	slices.Sort(names)
+
+	for _, name := range slices.Compact(names) {
+		img, err := openBestDensity(fsys, name)
+		if err != nil {
+			return group, err
+		}
+		// the status bar icons are 24dp, shown at half the tile on the bar
+		icon := resizePreview(img, tileSize/2)
+		group.tiles = append(group.tiles,
+			previewTile{label: fmt.Sprint(name, " light"), img: tintOnStatusBar(icon, tileSize, statusBarLightColor, statusBarLightIcon)},
+			previewTile{label: fmt.Sprint(name, " dark"), img: tintOnStatusBar(icon, tileSize, statusBarDarkColor, statusBarDarkIcon)},
+		)
+	}
+	return group, nil
+}
+
+// Only the alpha of the notification icons is used, the color is set by the system
+func tintOnStatusBar(icon *image.NRGBA, tileSize int, bar, tint color.NRGBA) *image.NRGBA {
+	tile := image.NewNRGBA(image.Rect(0, 0, tileSize, tileSize))
+	draw.Draw(tile, tile.Rect, image.NewUniform(bar), image.Point{}, draw.Src)
+
+	tinted := mapNRGBA(icon, func(x, y int, srcPx, dstPx []uint8) {
+		dstPx[0], dstPx[1], dstPx[2], dstPx[3] = tint.R, tint.G, tint.B, srcPx[3]
+	})
+	at := image.Pt((tileSize-tinted.Rect.Dx())/2, (tileSize-tinted.Rect.Dy())/2)
+	draw.Draw(tile, tinted.Rect.Add(at), tinted, image.Point{}, draw.Over)
+	return tile
+}
+
+func googlePlayLogoPreviewGroup(fsys fs.FS, tileSize int) (previewGroup, error) {
+	group := previewGroup{title: "google play"}
+
+	pngPaths, err := fs.Glob(fsys, "android/main/*.png")
+	if err != nil {
+		return group, err
+	}
+	for _, p := range pngPaths {
+		img, err := openPreviewImage(fsys, p)
+		if err != nil {
+			return group, err
+		}
+		group.tiles = append(group.tiles, previewTile{
+			label: strings.TrimSuffix(path.Base(p), ".png"),
+			img:   maskRoundedSquare(img, tileSize, googlePlayCornerRatio),
+		})
+	}
+	return group, nil
+}
+
+// The App Store (marketing) icons, the biggest of each appearance
+func iosAppIconPreviewGroup(fsys fs.FS, tileSize int) (previewGroup, error) {
+	group := previewGroup{title: "ios"}
+
+	pngPaths, err := fs.Glob(fsys, "ios/Assets.xcassets/*.appiconset/*~ios-marketing.png")
+	if err != nil {
+		return group, err
+	}
+	for _, p := range pngPaths {
+		img, err := openPreviewImage(fsys, p)
+		if err != nil {
+			return group, err
+		}
+		group.tiles = append(group.tiles, previewTile{
+			label: strings.TrimSuffix(path.Base(p), "~ios-marketing.png"),
+			img:   maskRoundedSquare(img, tileSize, iosCornerRatio),
+		})
+	}
+	return group, nil
+}
+
+const (
+	previewMargin      = 24
+	previewLabelHeight = 20
+)
+
+// One row per group: the title then the tiles with their labels under them
+func renderPreviewSheet(groups []previewGroup, tileSize int) *image.NRGBA {
+	face := basicfont.Face7x13
+
+	// the columns are wide enough for the longest label
+	maxTiles, tileW := 0, tileSize
+	for _, g := range groups {
+		maxTiles = max(maxTiles, len(g.tiles))
+		for _, t := range g.tiles {
+			tileW = max(tileW, len(t.label)*face.Advance)
+		}
+	}
+	rowH := previewLabelHeight + tileSize + previewLabelHeight + previewMargin
+	w := previewMargin + maxTiles*(tileW+previewMargin)
+	h := previewMargin + len(groups)*rowH
+
+	sheet := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(sheet, sheet.Rect, image.NewUniform(previewSheetColor), image.Point{}, draw.Src)
+
+	y := previewMargin
+	for _, g := range groups {
+		drawPreviewLabel(sheet, previewMargin, y, w-previewMargin, g.title)
+		y += previewLabelHeight
+
+		x := previewMargin
+		for _, t := range g.tiles {
+			at := image.Pt(x+(tileW-tileSize)/2, y)
+			draw.Draw(sheet, t.img.Rect.Add(at), t.img, image.Point{}, draw.Over)
+			drawPreviewLabel(sheet, x, y+tileSize+4, x+tileW, t.label)
+			x += tileW + previewMargin
+		}
+		y += tileSize + previewLabelHeight + previewMargin
+	}
+
+	return sheet
+}
+
+func drawPreviewLabel(dst *image.NRGBA, x, top, right int, label string) {
+	face := basicfont.Face7x13
+	d := font.Drawer{
+		Dst:  dst.SubImage(image.Rect(x, top, right, top+previewLabelHeight)).(*image.NRGBA),
+		Src:  image.NewUniform(previewTextColor),
+		Face: face,
+		Dot:  fixed.P(x, top+face.Ascent),
+	}
+	d.DrawString(label)
+}
+
+func renderPreviewHtml(groups []previewGroup) (string, error) {
+	sb := strings.Builder{}
+	sb.WriteString("<!DOCTYPE html>\n")
+	sb.WriteString(`<html><head><meta charset="utf-8"><title>assets-gen preview</title>`)
+	sb.WriteString(`<style>`)
+	sb.WriteString(`body{font-family:sans-serif;background:#f8f9fa;color:#202124;margin:24px}`)
+	sb.WriteString(`section{display:flex;flex-wrap:wrap;gap:24px;margin-bottom:32px}`)
+	sb.WriteString(`figure{margin:0;text-align:center}figcaption{font-size:13px;margin-top:4px}`)
+	sb.WriteString(`</style></head><body>`)
+	sb.WriteString("\n")
+
+	for _, g := range groups {
+		sb.WriteString(fmt.Sprint("<h2>", html.EscapeString(g.title), "</h2>\n<section>\n"))
+		for _, t := range g.tiles {
+			buf := bytes.Buffer{}
+			err := png.Encode(&buf, t.img)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(fmt.Sprint(
+				`<figure><img src="data:image/png;base64,`, base64.StdEncoding.EncodeToString(buf.Bytes()),
+				`" width="`, t.img.Rect.Dx(), `" height="`, t.img.Rect.Dy(), `" alt="`, html.EscapeString(t.label), `">`,
+				"<figcaption>", html.EscapeString(t.label), "</figcaption></figure>\n",
+			))
+		}
+		sb.WriteString("</section>\n")
+	}
+
+	sb.WriteString("</body></html>\n")
+	return sb.String(), nil
+}
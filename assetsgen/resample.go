@@ -0,0 +1,182 @@
+package assetsgen
+
+import (
+	"errors"
+	"image"
+	"slices"
+
+	"github.com/anthonynsimon/bild/transform"
+)
+
+var (
+	ErrInvalidResampleFilter = errors.New("invalid resample filter")
+	ErrInvalidResizeSpace    = errors.New("invalid resize space")
+)
+
+// The filter used to resize the images to the asset sizes
+type ResampleFilter string
+
+const (
+	// Keeps the hard edges, useful for pixel art
+	ResampleFilterNearest    ResampleFilter = "nearest"
+	ResampleFilterLinear     ResampleFilter = "linear"
+	ResampleFilterCatmullRom ResampleFilter = "catmull-rom"
+	ResampleFilterMitchell   ResampleFilter = "mitchell"
+	// The default, the sharpest for downscaling photos and gradients
+	ResampleFilterLanczos ResampleFilter = "lanczos"
+	ResampleFilterBox     ResampleFilter = "box"
+)
+
+var ResampleFilters = []ResampleFilter{
+	ResampleFilterNearest,
+	ResampleFilterLinear,
+	ResampleFilterCatmullRom,
+	ResampleFilterMitchell,
+	ResampleFilterLanczos,
+	ResampleFilterBox,
+}
+
+func ParseResampleFilter(s string) (ResampleFilter, error) {
+	f := ResampleFilter(s)
+	if !slices.Contains(ResampleFilters, f) {
+		return "", ErrInvalidResampleFilter
+	}
+	return f, nil
+}
+
+func (f ResampleFilter) transformFilter() transform.ResampleFilter {
+	switch f {
+	case ResampleFilterNearest:
+		return transform.NearestNeighbor
+	case ResampleFilterLinear:
+		return transform.Linear
+	case ResampleFilterCatmullRom:
+		return transform.CatmullRom
+	case ResampleFilterMitchell:
+		return transform.MitchellNetravali
+	case ResampleFilterBox:
+		return transform.Box
+	default:
+		return transform.Lanczos
+	}
+}
+
+// The color space the pixels are resampled in
+type ResizeSpace string
+
+const (
+	// Linear light with premultiplied alpha. Keeps the brightness of thin strokes and avoids the dark fringes around
+	// the transparent edges. The default for the icons
+	ResizeSpaceLinear ResizeSpace = "linear"
+	// Resamples the sRGB values as is, the default for the image assets
+	ResizeSpaceSrgb ResizeSpace = "srgb"
+)
+
+var ResizeSpaces = []ResizeSpace{ResizeSpaceLinear, ResizeSpaceSrgb}
+
+func ParseResizeSpace(s string) (ResizeSpace, error) {
+	space := ResizeSpace(s)
+	if !slices.Contains(ResizeSpaces, space) {
+		return "", ErrInvalidResizeSpace
+	}
+	return space, nil
+}
+
+type ResizeOptions struct {
+	// Empty falls back to lanczos
+	Filter ResampleFilter
+
+	// Empty falls back to the default of the target, see [ResizeSpace]
+	Space ResizeSpace
+
+	// Sharpens the outputs with max axis (w,h) smaller than this in pixels, 0 to disable
+	SharpenBelow int
+
+	// The strength of the sharpening, 0.5 is subtle and 1 is strong. Zero falls back to 0.5
+	SharpenAmount float64
+}
+
+func DefaultResizeOptions() ResizeOptions {
+	return ResizeOptions{Filter: ResampleFilterLanczos}
+}
+
+// Fills the empty [ResizeOptions.Space] with the default of the target
+func (o ResizeOptions) withDefaultSpace(space ResizeSpace) ResizeOptions {
+	if o.Space == "" {
+		o.Space = space
+	}
+	return o
+}
+
+func (o ResizeOptions) resize(img image.Image, w, h int) image.Image {
+	return o.resizeUsingFilter(img, w, h, o.Filter.transformFilter())
+}
+
+func (o ResizeOptions) resizeUsingFilter(img image.Image, w, h int, filter transform.ResampleFilter) image.Image {
+	// nearest picks the source pixels as they are, there is nothing to blend
+	if o.Space == ResizeSpaceLinear && filter.Support > 0 {
+		return resizeLinearLight(img, w, h, filter)
+	}
+	return transform.Resize(img, w, h, filter)
+}
+
+// The pyramid levels are box filtered which would blur the hard edges the nearest filter is chosen for
+func (o ResizeOptions) usePyramid() bool {
+	return o.Filter != ResampleFilterNearest
+}
+
+func (o ResizeOptions) shouldSharpen(w, h int) bool {
+	return o.SharpenBelow > 0 && max(w, h) < o.SharpenBelow
+}
+
+func (o ResizeOptions) sharpenAmount() float64 {
+	if o.SharpenAmount <= 0 {
+		return 0.5
+	}
+	return o.SharpenAmount
+}
+
+// Resizes to w,h then sharpens the result if it's below the sharpen threshold
+func (o ResizeOptions) resizeAndSharpen(img image.Image, w, h int) image.Image {
+	img = o.resize(img, w, h)
+	if o.shouldSharpen(w, h) {
+		img = unsharpMask(img, o.sharpenAmount())
+	}
+	return img
+}
+
+// An unsharp mask with a 3x3 blur, small enough for the icon sizes it's used on.
+// The blur is weighted by the alpha so the transparent pixels don't pull the edges of the logo to black,
+// and the alpha itself is kept as is so no halo is added around the logo.
+func unsharpMask(img image.Image, amount float64) *image.NRGBA {
+	src := asNRGBA(img)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	kernel := [3]float64{1, 2, 1}
+
+	return mapNRGBA(src, func(x, y int, srcPx, dstPx []uint8) {
+		dstPx[3] = srcPx[3]
+		if srcPx[3] == 0 {
+			return
+		}
+
+		var sumR, sumG, sumB, sumW float64
+		for ky := -1; ky <= 1; ky++ {
+			yy := min(max(y+ky, 0), h-1)
+			for kx := -1; kx <= 1; kx++ {
+				xx := min(max(x+kx, 0), w-1)
+				i := yy*src.Stride + xx*4
+				weight := kernel[kx+1] * kernel[ky+1] * float64(src.Pix[i+3])
+				sumR += weight * float64(src.Pix[i])
+				sumG += weight * float64(src.Pix[i+1])
+				sumB += weight * float64(src.Pix[i+2])
+				sumW += weight
+			}
+		}
+
+		blurred := [3]float64{sumR / sumW, sumG / sumW, sumB / sumW}
+		for c := range 3 {
+			v := float64(srcPx[c])
+			dstPx[c] = uint8(min(max(v+(v-blurred[c])*amount, 0), 255) + 0.5)
+		}
+	})
+}
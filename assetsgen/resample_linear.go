@@ -0,0 +1,176 @@
+package assetsgen
+
+import (
+	"image"
+	"math"
+	"sync"
+
+	"github.com/anthonynsimon/bild/transform"
+)
+
+// An image in linear light with the colors premultiplied by the alpha, all the channels are in [0..1].
+// Resampling in this form keeps the brightness of thin strokes and doesn't bleed the color of the transparent pixels into the edges.
+type linearImage struct {
+	w, h int
+	pix  []float32 // R, G, B, A per pixel
+}
+
+var (
+	srgbToLinearLUT     [256]float32
+	linearToSrgbLUT     []uint8
+	linearToSrgbLUTOnce sync.Once
+)
+
+// The linear to sRGB table is indexed by the linear value scaled to this, fine enough that the dark tones don't band
+const linearToSrgbLUTSize = 1 << 16
+
+func init() {
+	for i := range srgbToLinearLUT {
+		v := float64(i) / 255
+		if v <= 0.04045 {
+			srgbToLinearLUT[i] = float32(v / 12.92)
+		} else {
+			srgbToLinearLUT[i] = float32(math.Pow((v+0.055)/1.055, 2.4))
+		}
+	}
+}
+
+func linearToSrgb(v float32) uint8 {
+	linearToSrgbLUTOnce.Do(func() {
+		linearToSrgbLUT = make([]uint8, linearToSrgbLUTSize)
+		for i := range linearToSrgbLUT {
+			v := float64(i) / (linearToSrgbLUTSize - 1)
+			if v <= 0.0031308 {
+				v *= 12.92
+			} else {
+				v = 1.055*math.Pow(v, 1/2.4) - 0.055
+			}
+			linearToSrgbLUT[i] = uint8(v*255 + 0.5)
+		}
+	})
+
+	i := int(v*(linearToSrgbLUTSize-1) + 0.5)
+	return linearToSrgbLUT[min(max(i, 0), linearToSrgbLUTSize-1)]
+}
+
+func newLinearImage(img image.Image) linearImage {
+	src := asNRGBA(img)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	l := linearImage{w: w, h: h, pix: make([]float32, w*h*4)}
+
+	parallelRows(h, func(y int) {
+		srcRow := src.Pix[y*src.Stride : y*src.Stride+w*4]
+		dstRow := l.pix[y*w*4 : (y+1)*w*4]
+		for i := 0; i < len(srcRow); i += 4 {
+			a := float32(srcRow[i+3]) / 255
+			dstRow[i] = srgbToLinearLUT[srcRow[i]] * a
+			dstRow[i+1] = srgbToLinearLUT[srcRow[i+1]] * a
+			dstRow[i+2] = srgbToLinearLUT[srcRow[i+2]] * a
+			dstRow[i+3] = a
+		}
+	})
+
+	return l
+}
+
+func (l linearImage) toNRGBA() *image.NRGBA {
+	dst := image.NewNRGBA(image.Rect(0, 0, l.w, l.h))
+
+	parallelRows(l.h, func(y int) {
+		srcRow := l.pix[y*l.w*4 : (y+1)*l.w*4]
+		dstRow := dst.Pix[y*dst.Stride : y*dst.Stride+l.w*4]
+		for i := 0; i < len(srcRow); i += 4 {
+			// the filters with negative lobes can overshoot. The colors are divided by the alpha before it's clamped,
+			// otherwise an overshoot above 1 brightens the opaque pixels next to the edges
+			a := srcRow[i+3]
+			if a <= 0 {
+				continue
+			}
+			dstRow[i] = linearToSrgb(min(max(srcRow[i]/a, 0), 1))
+			dstRow[i+1] = linearToSrgb(min(max(srcRow[i+1]/a, 0), 1))
+			dstRow[i+2] = linearToSrgb(min(max(srcRow[i+2]/a, 0), 1))
+			dstRow[i+3] = uint8(min(a, 1)*255 + 0.5)
+		}
+	})
+
+	return dst
+}
+
+// The source pixels and their normalized weights for one destination pixel
+type resampleTaps struct {
+	start   int
+	weights []float32
+}
+
+// Computes the taps of every destination pixel along one axis.
+// When downscaling the filter is stretched by the scale so every source pixel contributes.
+func resampleAxisTaps(srcSize, dstSize int, filter transform.ResampleFilter) []resampleTaps {
+	scale := float64(srcSize) / float64(dstSize)
+	filterScale := max(scale, 1)
+	support := filter.Support * filterScale
+
+	taps := make([]resampleTaps, dstSize)
+	for i := range taps {
+		center := (float64(i)+0.5)*scale - 0.5
+		start := int(math.Ceil(center - support))
+		end := int(math.Floor(center + support))
+
+		weights := make([]float32, 0, end-start+1)
+		sum := 0.0
+		for j := start; j <= end; j++ {
+			w := filter.Fn((float64(j) - center) / filterScale)
+			weights = append(weights, float32(w))
+			sum += w
+		}
+		if sum != 0 {
+			for k := range weights {
+				weights[k] /= float32(sum)
+			}
+		}
+
+		taps[i] = resampleTaps{start: start, weights: weights}
+	}
+	return taps
+}
+
+// Separable resampling, horizontally then vertically. The pixels outside the image are clamped to the edges.
+func (l linearImage) resample(w, h int, filter transform.ResampleFilter) linearImage {
+	xTaps := resampleAxisTaps(l.w, w, filter)
+	horizontal := linearImage{w: w, h: l.h, pix: make([]float32, w*l.h*4)}
+	parallelRows(l.h, func(y int) {
+		srcRow := l.pix[y*l.w*4 : (y+1)*l.w*4]
+		dstRow := horizontal.pix[y*w*4 : (y+1)*w*4]
+		for x, t := range xTaps {
+			var r, g, b, a float32
+			for k, weight := range t.weights {
+				i := min(max(t.start+k, 0), l.w-1) * 4
+				r += srcRow[i] * weight
+				g += srcRow[i+1] * weight
+				b += srcRow[i+2] * weight
+				a += srcRow[i+3] * weight
+			}
+			dstRow[x*4], dstRow[x*4+1], dstRow[x*4+2], dstRow[x*4+3] = r, g, b, a
+		}
+	})
+
+	yTaps := resampleAxisTaps(l.h, h, filter)
+	vertical := linearImage{w: w, h: h, pix: make([]float32, w*h*4)}
+	parallelRows(h, func(y int) {
+		t := yTaps[y]
+		dstRow := vertical.pix[y*w*4 : (y+1)*w*4]
+		for k, weight := range t.weights {
+			yy := min(max(t.start+k, 0), l.h-1)
+			srcRow := horizontal.pix[yy*w*4 : (yy+1)*w*4]
+			for i, v := range srcRow {
+				dstRow[i] += v * weight
+			}
+		}
+	})
+
+	return vertical
+}
+
+// Resizes in linear light with premultiplied alpha, the result is not premultiplied
+func resizeLinearLight(img image.Image, w, h int, filter transform.ResampleFilter) *image.NRGBA {
+	return newLinearImage(img).resample(w, h, filter).toNRGBA()
+}
@@ -0,0 +1,110 @@
+package assetsgen
+
+import (
+	"bytes"
+	"flag"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// A #3366CC anti-aliased ring on transparent pixels that carry red, a resize that bleeds the color of the
+// transparent pixels turns the edges of the ring purple
+func ringNRGBA(size int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	c := float64(size) / 2
+	outer, inner := float64(size)*0.45, float64(size)*0.3
+	for y := range size {
+		for x := range size {
+			d := math.Hypot(float64(x)+0.5-c, float64(y)+0.5-c)
+			a := math.Max(0, math.Min(1, math.Min(outer-d+0.5, d-inner+0.5)))
+			if a == 0 {
+				img.SetNRGBA(x, y, color.NRGBA{R: 255, A: 0})
+				continue
+			}
+			img.SetNRGBA(x, y, color.NRGBA{R: 0x33, G: 0x66, B: 0xcc, A: uint8(a*255 + 0.5)})
+		}
+	}
+	return img
+}
+
+func TestResizeLinearRingGolden(t *testing.T) {
+	src, err := NewSourceImage(ringNRGBA(1024), "ring.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := NewMemorySink()
+	err = GenerateAppIconForAndroidFromSource(src, AndroidAppIconOptions{
+		BgIcon:         NewSolidColorBackground(colorful.Color{R: 1, G: 1, B: 1}),
+		AlphaThreshold: 0.5,
+		FolderName:     AndroidFolderMipmap,
+		OutputFileName: "ic_launcher",
+		Resize:         ResizeOptions{Filter: ResampleFilterLanczos, Space: ResizeSpaceLinear},
+		Output:         output,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const name = "android/res/mipmap-mdpi/ic_launcher_foreground.png"
+	data, ok := output.File(name)
+	if !ok {
+		t.Fatalf("%s was not generated", name)
+	}
+	decoded, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := asNRGBA(decoded)
+
+	// the premultiplied resize keeps the color of every visible pixel, up to the rounding
+	for y := got.Rect.Min.Y; y < got.Rect.Max.Y; y++ {
+		for x := got.Rect.Min.X; x < got.Rect.Max.X; x++ {
+			c := got.NRGBAAt(x, y)
+			if c.A == 0 {
+				continue
+			}
+			if absDiff(c.R, 0x33) > 1 || absDiff(c.G, 0x66) > 1 || absDiff(c.B, 0xcc) > 1 {
+				t.Fatalf("the pixel (%d,%d) is %v, want #3366CC with no darkening or red bleeding", x, y, c)
+			}
+		}
+	}
+
+	golden := filepath.Join("testdata", "ring_mdpi_foreground.png")
+	if *updateGolden {
+		err = os.WriteFile(golden, data, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// compared by the pixels, the encoded bytes depend on the png encoder
+	goldenFile, err := os.Open(golden)
+	if err != nil {
+		t.Fatalf("%v, run the test with -update to create it", err)
+	}
+	defer goldenFile.Close()
+	want, err := png.Decode(goldenFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Rect.Eq(want.Bounds()) || !bytes.Equal(got.Pix, asNRGBA(want).Pix) {
+		t.Errorf("%s differs from %s, run the test with -update if the change is intended", name, golden)
+	}
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
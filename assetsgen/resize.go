@@ -0,0 +1,120 @@
+package assetsgen
+
+import (
+	"image"
+	"runtime"
+	"slices"
+	"sync"
+
+	"github.com/anthonynsimon/bild/transform"
+)
+
+// The minimum ratio between a pyramid level and the target size for the level to be used as the resize source.
+// At 2x or more the final filter still sees every source pixel of the level, so the quality matches resizing from the full image.
+const resizePyramidMinRatio = 2
+
+// A progressive downscale pyramid of one source image.
+// levels[0] is the source image, and every level is half the size of the previous one.
+type resizePyramid struct {
+	levels []image.Image
+}
+
+// Builds the levels down to what the smallest target (minW, minH) needs
+func newResizePyramid(src image.Image, minW, minH int, options ResizeOptions) resizePyramid {
+	levels := []image.Image{src}
+	for {
+		b := levels[len(levels)-1].Bounds()
+		halfW, halfH := (b.Dx()+1)/2, (b.Dy()+1)/2
+		if halfW < minW*resizePyramidMinRatio || halfH < minH*resizePyramidMinRatio {
+			break
+		}
+		// box filter at exactly 2x is an average of 2x2 pixels, in the same color space as the final resize
+		levels = append(levels, options.resizeUsingFilter(levels[len(levels)-1], halfW, halfH, transform.Box))
+	}
+	return resizePyramid{levels: levels}
+}
+
+// The smallest level that is still at least [resizePyramidMinRatio] times the target size, or the source image.
+func (p resizePyramid) levelFor(w, h int) image.Image {
+	for i := len(p.levels) - 1; i > 0; i-- {
+		b := p.levels[i].Bounds()
+		if b.Dx() >= w*resizePyramidMinRatio && b.Dy() >= h*resizePyramidMinRatio {
+			return p.levels[i]
+		}
+	}
+	return p.levels[0]
+}
+
+type resizeJob struct {
+	src  image.Image
+	size image.Point
+}
+
+// Resizes every image to its target size.
+// The identical (image, size) pairs are resized once and share the result, the smaller sizes are derived from
+// the nearest larger pyramid level instead of the full resolution image, and the resizes run in parallel.
+// The images that are already at their target size are returned as is.
+func planResizes(images []image.Image, sizes []image.Point, options ResizeOptions) []image.Image {
+	// the distinct sizes per source image
+	sizesPerSrc := make(map[image.Image][]image.Point)
+	var srcOrder []image.Image
+	for i, img := range images {
+		if img.Bounds().Size() == sizes[i] {
+			continue
+		}
+		if _, ok := sizesPerSrc[img]; !ok {
+			srcOrder = append(srcOrder, img)
+		}
+		if !slices.Contains(sizesPerSrc[img], sizes[i]) {
+			sizesPerSrc[img] = append(sizesPerSrc[img], sizes[i])
+		}
+	}
+
+	var jobs []resizeJob
+	pyramids := make(map[image.Image]resizePyramid, len(srcOrder))
+	for _, img := range srcOrder {
+		s := sizesPerSrc[img]
+		if options.usePyramid() {
+			minW := slices.MinFunc(s, func(a, b image.Point) int { return a.X - b.X }).X
+			minH := slices.MinFunc(s, func(a, b image.Point) int { return a.Y - b.Y }).Y
+			pyramids[img] = newResizePyramid(img, minW, minH, options)
+		} else {
+			pyramids[img] = resizePyramid{levels: []image.Image{img}}
+		}
+
+		for _, size := range s {
+			jobs = append(jobs, resizeJob{src: img, size: size})
+		}
+	}
+
+	results := make([]image.Image, len(jobs))
+	jobsCh := make(chan int)
+	wg := sync.WaitGroup{}
+	for range min(runtime.GOMAXPROCS(0), len(jobs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobsCh {
+				job := jobs[i]
+				results[i] = options.resizeAndSharpen(pyramids[job.src].levelFor(job.size.X, job.size.Y), job.size.X, job.size.Y)
+			}
+		}()
+	}
+	for i := range jobs {
+		jobsCh <- i
+	}
+	close(jobsCh)
+	wg.Wait()
+
+	out := make([]image.Image, len(images))
+	for i, img := range images {
+		out[i] = img
+		for j, job := range jobs {
+			if job.src == img && job.size == sizes[i] {
+				out[i] = results[j]
+				break
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,141 @@
+package assetsgen
+
+import (
+	"fmt"
+	"image"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/anthonynsimon/bild/imgio"
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+// A decoded source image that can be shared between the generators, e.g: to generate all the assets from one decode.
+//
+// The preprocessing steps (trim, padding, mask) are computed once per options and shared too.
+// The shared images are never modified, every generator step writes into a new image (copy on write).
+// It is safe for concurrent use.
+type SourceImage struct {
+	img               image.Image
+	imagePath         string
+	imageName         string
+	imgNameWithoutExt string
+	imageExt          string
+	encoder           imgio.Encoder
+
+	mu    sync.Mutex
+	cache map[string]*sourceImageCacheEntry
+}
+
+type sourceImageCacheEntry struct {
+	once sync.Once
+	img  image.Image
+}
+
+func LoadSourceImage(imagePath string) (*SourceImage, error) {
+	if err := IsFileExistsAndImage(imagePath); err != nil {
+		return nil, err
+	}
+
+	img, err := imgio.Open(imagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := NewSourceImage(img, imagePath)
+	if err != nil {
+		return nil, err
+	}
+	src.imagePath = imagePath
+	return src, nil
+}
+
+// For the images that are not read from a file, e.g: an upload. The extension of [name] picks the output format
+func NewSourceImage(img image.Image, name string) (*SourceImage, error) {
+	enc, err := imageEncoderFromPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	imgName := filepath.Base(name)
+	imageExt := filepath.Ext(name)
+
+	return &SourceImage{
+		img:               img,
+		encoder:           enc,
+		imageName:         imgName,
+		imageExt:          imageExt,
+		imgNameWithoutExt: strings.ReplaceAll(imgName, imageExt, ""),
+		cache:             make(map[string]*sourceImageCacheEntry),
+	}, nil
+}
+
+func (src *SourceImage) Image() image.Image {
+	return src.img
+}
+
+// The bounds of the logo in the source image that the trim keeps, see [DetectContentRect]
+func (src *SourceImage) ContentRect(options TrimOptions) (image.Rectangle, bool) {
+	return DetectContentRect(src.img, options)
+}
+
+// Computes the image once per key, the concurrent callers with the same key wait for the first one
+func (src *SourceImage) memo(key string, fn func() image.Image) image.Image {
+	src.mu.Lock()
+	entry, ok := src.cache[key]
+	if !ok {
+		entry = &sourceImageCacheEntry{}
+		src.cache[key] = entry
+	}
+	src.mu.Unlock()
+
+	entry.once.Do(func() { entry.img = fn() })
+	return entry.img
+}
+
+// The source with the background keyed out and trimmed per [TrimOptions], the key out is applied even if trim is false
+func (src *SourceImage) trimmed(trim bool, options TrimOptions) image.Image {
+	if !trim && !options.KeyOut {
+		return src.img
+	}
+
+	return src.memo(fmt.Sprint("trim:", trim, " ", options.key()), func() image.Image {
+		imgInfo := &imageInfo{img: src.img}
+		if trim {
+			return imgInfo.Trim(options).img
+		}
+		return imgInfo.KeyOutBackground(options).img
+	})
+}
+
+// The trimmed, squared and padded logo with the alpha threshold and the mask applied in that order.
+// [opticalCentering] between [0..1] see [imageInfo.SquareImageWithOpticalCenter]
+// [padding] between [0..1] as percentage of the maximum axis (w,h) of the source image
+// [alphaThreshold] -1 to disable
+func (src *SourceImage) logo(trim bool, trimOptions TrimOptions, opticalCentering float64, padding float64, alphaThreshold float64, mask *colorful.Color) image.Image {
+	pad := calPadding(src.img, padding)
+
+	key := fmt.Sprint("square:", trim, " ", trimOptions.key(), " optical:", opticalCentering, " pad:", pad)
+	logo := src.memo(key, func() image.Image {
+		return (&imageInfo{img: src.trimmed(trim, trimOptions)}).SquareImageWithOpticalCenter(pad, opticalCentering).img
+	})
+
+	if alphaThreshold >= 0 {
+		squared := logo
+		key = fmt.Sprint(key, " threshold:", alphaThreshold)
+		logo = src.memo(key, func() image.Image {
+			return (&imageInfo{img: squared}).RemoveAlphaOnThreshold(alphaThreshold).img
+		})
+	}
+
+	if mask != nil {
+		unmasked := logo
+		key = fmt.Sprint(key, " mask:", mask.Hex())
+		logo = src.memo(key, func() image.Image {
+			return (&imageInfo{img: unmasked}).ConvertNoneOpaqueToColor(*mask).img
+		})
+	}
+
+	return logo
+}
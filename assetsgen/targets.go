@@ -0,0 +1,615 @@
+package assetsgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/lucasb-eyer/go-colorful"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrInvalidTargetSpec = errors.New("invalid target spec")
+
+// A list of icon targets to generate from a spec file, to support new stores and platforms with no Go code.
+// e.g: a Huawei AppGallery 216px icon:
+//
+//	{"targets": [{"name": "huawei-appgallery", "dir": "huawei", "sizes": [{"size": 216}], "shape": "rounded-square", "cornerRadius": 0.3}]}
+type TargetSpec struct {
+	Targets []Target `json:"targets"`
+}
+
+type TargetShape string
+
+const (
+	TargetShapeSquare        TargetShape = "square"
+	TargetShapeRoundedSquare TargetShape = "rounded-square"
+	TargetShapeCircle        TargetShape = "circle"
+)
+
+type TargetBackground string
+
+const (
+	// The background of the options under the logo
+	TargetBackgroundFill TargetBackground = "fill"
+	// The logo only
+	TargetBackgroundTransparent TargetBackground = "transparent"
+	// The background of the options without the logo, e.g: the background layer of the Android adaptive icons
+	TargetBackgroundOnly TargetBackground = "only"
+)
+
+type TargetAlpha string
+
+const (
+	TargetAlphaKeep TargetAlpha = "keep"
+	// No alpha channel, e.g: for the App Store. Only for the square targets with a fill background
+	TargetAlphaOpaque TargetAlpha = "opaque"
+)
+
+type Target struct {
+	// Unique in the spec, e.g: huawei-appgallery
+	Name string `json:"name"`
+
+	// The folder of the files relative to the output, a text/template of [TargetFile]. e.g: android/res/mipmap-{{.Label}}
+	Dir string `json:"dir"`
+
+	// The name of the files without the extension, a text/template of [TargetFile]. Defaults to {{.Target}}_{{.Size}}
+	FileName string `json:"fileName,omitempty"`
+
+	// The size in pixels of the sizes with a scale, e.g: 48 for the Android launcher icons with the density scales
+	BaseSize int `json:"baseSize,omitempty"`
+
+	Sizes []TargetSize `json:"sizes"`
+
+	// Defaults to square
+	Shape TargetShape `json:"shape,omitempty"`
+
+	// Between [0..1] as percentage of the radius of the rounded-square shape
+	CornerRadius float64 `json:"cornerRadius,omitempty"`
+
+	// Defaults to fill
+	Background TargetBackground `json:"background,omitempty"`
+
+	// Defaults to keep
+	Alpha TargetAlpha `json:"alpha,omitempty"`
+
+	// Paints the logo with this color instead of the mask color of the options, e.g: #FFFFFF for the notification icons
+	Tint string `json:"tint,omitempty"`
+
+	// Between [0..1], overrides the padding of the options
+	Padding *float64 `json:"padding,omitempty"`
+
+	// An optional file listing the generated files, e.g: the Contents.json of an iOS asset catalog
+	Manifest *TargetManifest `json:"manifest,omitempty"`
+}
+
+type TargetSize struct {
+	// For the templates, e.g: xxhdpi or 2x
+	Label string `json:"label,omitempty"`
+
+	// The width and height in pixels, zero to use the scale of the base size
+	Size int `json:"size,omitempty"`
+
+	Scale float64 `json:"scale,omitempty"`
+
+	// Free values for the templates, e.g: the idiom of the iOS icons
+	Meta map[string]string `json:"meta,omitempty"`
+}
+
+type TargetManifest struct {
+	// Relative to the output
+	Path string `json:"path"`
+
+	// A text/template of [TargetManifestData]. The json func quotes a value for the JSON manifests, e.g: {{json .Target}}
+	Template string `json:"template"`
+}
+
+// The data of the dir and the file name templates. Dir and FileName are set for the manifest template only
+type TargetFile struct {
+	Target string
+	Label  string
+	Size   int
+	Scale  float64
+	Meta   map[string]string
+
+	Dir string
+	// With the extension
+	FileName string
+}
+
+type TargetManifestData struct {
+	Target string
+	Files  []TargetFile
+}
+
+// Reads a JSON or YAML spec, see [ParseTargetSpec]
+func LoadTargetSpec(specPath string) (TargetSpec, error) {
+	data, err := os.ReadFile(specPath)
+	if err != nil {
+		return TargetSpec{}, err
+	}
+	return ParseTargetSpec(data)
+}
+
+// Parses and validates a JSON or YAML spec (YAML is a superset of JSON). The unknown fields are an error
+func ParseTargetSpec(data []byte) (TargetSpec, error) {
+	// YAML is decoded to generic values and back to JSON to share the json tags and their checks
+	var raw any
+	err := yaml.Unmarshal(data, &raw)
+	if err != nil {
+		return TargetSpec{}, fmt.Errorf("%w: %w", ErrInvalidTargetSpec, err)
+	}
+	jsonData, err := json.Marshal(raw)
+	if err != nil {
+		return TargetSpec{}, fmt.Errorf("%w: %w", ErrInvalidTargetSpec, err)
+	}
+
+	var spec TargetSpec
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&spec)
+	if err != nil {
+		return TargetSpec{}, fmt.Errorf("%w: %w", ErrInvalidTargetSpec, err)
+	}
+
+	return spec, spec.Validate()
+}
+
+func (spec TargetSpec) Validate() error {
+	names := map[string]bool{}
+	for _, t := range spec.Targets {
+		if names[t.Name] {
+			return fmt.Errorf("%w: duplicated target name %q", ErrInvalidTargetSpec, t.Name)
+		}
+		names[t.Name] = true
+
+		err := t.validate()
+		if err != nil {
+			return fmt.Errorf("%w: target %q: %w", ErrInvalidTargetSpec, t.Name, err)
+		}
+	}
+	return nil
+}
+
+func (t Target) validate() error {
+	if t.Name == "" {
+		return errors.New("the name is required")
+	}
+	if len(t.Sizes) == 0 {
+		return errors.New("at least one size is required")
+	}
+	for _, s := range t.Sizes {
+		if s.Size < 0 || s.Scale < 0 || (s.Size == 0 && (s.Scale == 0 || t.BaseSize <= 0)) {
+			return errors.New("every size needs a size, or a scale and the base size of the target")
+		}
+	}
+
+	switch t.Shape {
+	case "", TargetShapeSquare, TargetShapeCircle:
+	case TargetShapeRoundedSquare:
+		if t.CornerRadius <= 0 || t.CornerRadius > 1 {
+			return errors.New("cornerRadius should be between 0..1 for the rounded-square shape")
+		}
+	default:
+		return fmt.Errorf("invalid shape %q. possible values (square, rounded-square, circle)", t.Shape)
+	}
+
+	switch t.Background {
+	case "", TargetBackgroundFill, TargetBackgroundTransparent, TargetBackgroundOnly:
+	default:
+		return fmt.Errorf("invalid background %q. possible values (fill, transparent, only)", t.Background)
+	}
+
+	switch t.Alpha {
+	case "", TargetAlphaKeep:
+	case TargetAlphaOpaque:
+		if t.Background == TargetBackgroundTransparent || (t.Shape != "" && t.Shape != TargetShapeSquare) {
+			return errors.New("the opaque alpha needs a fill or only background and the square shape")
+		}
+	default:
+		return fmt.Errorf("invalid alpha %q. possible values (keep, opaque)", t.Alpha)
+	}
+
+	if t.Tint != "" {
+		if _, _, err := ParseHexColor(t.Tint); err != nil {
+			return err
+		}
+	}
+	if t.Padding != nil && (*t.Padding < 0 || *t.Padding > 1) {
+		return errors.New("padding should be between 0..1")
+	}
+	if t.Manifest != nil && !isLocalSlashPath(t.Manifest.Path) {
+		return errors.New("the manifest needs a relative path inside the output")
+	}
+
+	// run here to report the template and the path errors before generating anything
+	_, err := t.files("")
+	return err
+}
+
+var manifestFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+type targetTemplates struct {
+	dir, fileName, manifest *template.Template
+}
+
+func (t Target) templates() (targetTemplates, error) {
+	fileName := t.FileName
+	if fileName == "" {
+		fileName = "{{.Target}}_{{.Size}}"
+	}
+
+	var out targetTemplates
+	var err error
+	out.dir, err = template.New("dir").Option("missingkey=error").Parse(t.Dir)
+	if err != nil {
+		return out, err
+	}
+	out.fileName, err = template.New("fileName").Option("missingkey=error").Parse(fileName)
+	if err != nil {
+		return out, err
+	}
+	if t.Manifest != nil {
+		out.manifest, err = template.New("manifest").Option("missingkey=error").Funcs(manifestFuncs).Parse(t.Manifest.Template)
+	}
+	return out, err
+}
+
+// The files of the target with their dir and file name, [ext] with the dot
+func (t Target) files(ext string) ([]TargetFile, error) {
+	tmpls, err := t.templates()
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]TargetFile, len(t.Sizes))
+	for i, s := range t.Sizes {
+		size := s.Size
+		if size == 0 {
+			size = int(math.Round(float64(t.BaseSize) * s.Scale))
+		}
+		f := TargetFile{Target: t.Name, Label: s.Label, Size: size, Scale: s.Scale, Meta: s.Meta}
+
+		sb := strings.Builder{}
+		err = tmpls.dir.Execute(&sb, f)
+		if err != nil {
+			return nil, err
+		}
+		f.Dir = path.Clean(sb.String())
+		if !isLocalSlashPath(f.Dir) {
+			return nil, fmt.Errorf("the dir %q should be relative and inside the output", f.Dir)
+		}
+
+		sb.Reset()
+		err = tmpls.fileName.Execute(&sb, f)
+		if err != nil {
+			return nil, err
+		}
+		f.FileName = fmt.Sprint(sb.String(), ext)
+		if strings.Contains(f.FileName, "/") {
+			return nil, fmt.Errorf("the file name %q should not have folders, see the dir of the target", f.FileName)
+		}
+
+		files[i] = f
+	}
+	return files, nil
+}
+
+// e.g: not /etc or ../out
+func isLocalSlashPath(p string) bool {
+	return p != "" && filepath.IsLocal(filepath.FromSlash(p))
+}
+
+type targetAsset struct {
+	file TargetFile
+}
+
+func (a targetAsset) Name() string {
+	return strings.TrimSuffix(a.file.FileName, path.Ext(a.file.FileName))
+}
+
+func (a targetAsset) CalcSize(_, _ int) (int, int) {
+	return a.file.Size, a.file.Size
+}
+
+func (a targetAsset) DirName() string {
+	return a.file.Dir
+}
+
+type TargetOptions struct {
+	// between [0..1] as percentage of how match the pixel should be transparent to keep its original color. Use -1 to disable
+	AlphaThreshold float64
+
+	// Required by the targets with a fill background
+	BgIcon BackgroundIcon
+
+	// between [0..1] as percentage of the maximum axis (w,h) of the image. See [Target.Padding]
+	Padding float64
+
+	// removes the white spaces from the edges of the logo
+	TrimWhiteSpace bool
+
+	// The background color to trim and key out, e.g: for JPG logos on white. Transparent only by default
+	Trim TrimOptions
+
+	// Between [0..1] moves the logo toward its visual center (the alpha weighted centroid) instead of the center
+	// of its bounding box. 0 to disable, 0.5 suits most triangles and letters like "L"
+	OpticalCentering float64
+
+	MaskColor *colorful.Color
+
+	// The filter and the sharpening used to resize to the asset sizes
+	Resize ResizeOptions
+
+	// Where the files are written to. Nil falls back to [RootFolderName] in the working directory, see [NewDirSink]
+	Output OutputSink
+}
+
+func GenerateTargets(imagePath string, spec TargetSpec, option TargetOptions) error {
+	src, err := LoadSourceImage(imagePath)
+	if err != nil {
+		return err
+	}
+	return GenerateTargetsFromSource(src, spec, option)
+}
+
+func GenerateTargetsFromSource(src *SourceImage, spec TargetSpec, option TargetOptions) error {
+	err := spec.Validate()
+	if err != nil {
+		return err
+	}
+
+	// opened once for all the targets
+	if option.Output == nil {
+		dirSink, err := NewDirSink("")
+		if err != nil {
+			return err
+		}
+		defer dirSink.Close()
+		option.Output = dirSink
+	}
+
+	for _, t := range spec.Targets {
+		err = generateTarget(src, t, option)
+		if err != nil {
+			return fmt.Errorf("target %q: %w", t.Name, err)
+		}
+	}
+	return nil
+}
+
+func generateTarget(src *SourceImage, t Target, option TargetOptions) error {
+	padding := option.Padding
+	if t.Padding != nil {
+		padding = *t.Padding
+	}
+
+	mask := option.MaskColor
+	if t.Tint != "" {
+		tint, _, _ := ParseHexColor(t.Tint)
+		mask = &tint
+	}
+
+	transparent := t.Background == TargetBackgroundTransparent
+	logoAlphaThreshold := -1.0
+	if transparent {
+		// otherwise applied when stacked on the background
+		logoAlphaThreshold = option.AlphaThreshold
+	}
+
+	logoImage, err := newImageInfoFromSource(
+		src,
+		src.logo(option.TrimWhiteSpace, option.Trim, option.OpticalCentering, padding, logoAlphaThreshold, mask),
+		option.Output,
+		"",
+	)
+	if err != nil {
+		return err
+	}
+	logoImage.SetResizeOptions(option.Resize.withDefaultSpace(ResizeSpaceLinear)).
+		If(option.Trim.KeyOut || t.Alpha != TargetAlphaOpaque, logoImage.EncodeWithAlpha)
+
+	img := logoImage
+	if !transparent {
+		if option.BgIcon == nil {
+			return errors.New("the fill and only backgrounds need a BgIcon")
+		}
+		bgImage, err := option.BgIcon.generateImgInfo(logoImage)
+		if err != nil {
+			return err
+		}
+
+		opaque := t.Alpha == TargetAlphaOpaque
+		switch {
+		case t.Background == TargetBackgroundOnly && opaque:
+			img = bgImage.RemoveAlpha()
+		case t.Background == TargetBackgroundOnly:
+			img = bgImage
+		case option.AlphaThreshold < 0 && opaque:
+			img = bgImage.StackOver(logoImage).RemoveAlpha()
+		case option.AlphaThreshold < 0:
+			img = bgImage.StackOver(logoImage)
+		case opaque:
+			img = bgImage.StackWithNoAlpha(option.AlphaThreshold, logoImage)
+		default:
+			img = bgImage.StackWithBgAlpha(option.AlphaThreshold, logoImage)
+		}
+	}
+
+	switch t.Shape {
+	case TargetShapeRoundedSquare:
+		img = img.ClipRRect(t.CornerRadius)
+	case TargetShapeCircle:
+		img = img.ClipRRect(1)
+	}
+
+	files, err := t.files(logoImage.imageExt)
+	if err != nil {
+		return err
+	}
+	assets := make([]asset, len(files))
+	for i, f := range files {
+		assets[i] = targetAsset{file: f}
+	}
+
+	imgs := img.SplitPerAsset(assets).ResizeForAssets()
+	for _, v := range *imgs {
+		err = v.SaveWithCustomName(v.asset.Name())
+		if err != nil {
+			return err
+		}
+	}
+
+	if t.Manifest == nil {
+		return nil
+	}
+	tmpls, err := t.templates()
+	if err != nil {
+		return err
+	}
+	sb := strings.Builder{}
+	err = tmpls.manifest.Execute(&sb, TargetManifestData{Target: t.Name, Files: files})
+	if err != nil {
+		return err
+	}
+	return writeFile(option.Output, path.Clean(t.Manifest.Path), []byte(sb.String()))
+}
+
+// Every built-in target in the spec format, derived from the dpi tables of the generators. A starting point for
+// the custom targets, generating it writes the same files with the same sizes as the generators. It is a description only:
+// the launcher icons of the spec place the logo with a fixed padding instead of the padding of the options,
+// and the legacy launcher icon is clipped to a circle, i.e. the default corner radius
+func BuiltinTargetsDescription() TargetSpec {
+	iosSizes := make([]TargetSize, len(iosAppIconDpis))
+	for i, v := range iosAppIconDpis {
+		dpi := v.(iosAppIconDpiAsset)
+		iosSizes[i] = TargetSize{
+			Label: dpi.Filename,
+			Size:  dpi.Size,
+			Meta:  map[string]string{"idiom": dpi.Idiom, "scale": dpi.Scale, "size": dpi.SizeName},
+		}
+	}
+
+	const notificationBaseSize = 24
+	notificationDpis := androidNotificationIconDpis(string(AndroidFolderMipmap))
+	notificationSizes := make([]TargetSize, len(notificationDpis))
+	for i, v := range notificationDpis {
+		dpi := v.(androidNotificationIconDpiAsset)
+		notificationSizes[i] = TargetSize{Label: dpi.dpiName, Scale: float64(dpi.width) / notificationBaseSize}
+	}
+
+	const legacyLauncherBaseSize = 48
+	legacyLauncherSizes := androidLauncherTargetSizes(androidAppIconDpisLegacyLayer(string(AndroidFolderMipmap)), legacyLauncherBaseSize)
+	// the logo is 44dp in the 48dp of the legacy icon
+	legacyLauncherPadding := 2.0 / 44
+
+	const adaptiveLayerBaseSize = 108
+	adaptiveLayerSizes := androidLauncherTargetSizes(androidAdaptiveAppIconLayerDpisV26(string(AndroidFolderMipmap)), adaptiveLayerBaseSize)
+	// the logo is 66dp in the 108dp of the adaptive layers
+	adaptiveLogoPadding := 21.0 / 66
+
+	noPadding := 0.0
+	launcherDir := path.Join(PlatformTypeAndroid, "res", "mipmap-{{.Label}}")
+
+	return TargetSpec{
+		Targets: []Target{
+			{
+				Name:     "ios-app-icon",
+				Dir:      path.Join(PlatformTypeIos, "Assets.xcassets", "AppIcon.appiconset"),
+				FileName: "{{.Label}}",
+				Sizes:    iosSizes,
+				Alpha:    TargetAlphaOpaque,
+				Manifest: &TargetManifest{
+					Path: path.Join(PlatformTypeIos, "Assets.xcassets", "AppIcon.appiconset", "Contents.json"),
+					Template: `{"images":[{{range $i, $f := .Files}}{{if $i}},{{end}}` +
+						`{"filename":{{json $f.FileName}},"idiom":{{json $f.Meta.idiom}},"scale":{{json $f.Meta.scale}},"size":{{json $f.Meta.size}}}` +
+						`{{end}}],"info":{"author":"https://github.com/Nidal-Bakir/assets-gen","version":1}}`,
+				},
+			},
+			{
+				Name:     "android-play-store-logo",
+				Dir:      path.Join(PlatformTypeAndroid, "main"),
+				FileName: "play_store_logo_512x512",
+				Sizes:    []TargetSize{{Size: googlePlayLogoSize}},
+				Alpha:    TargetAlphaOpaque,
+			},
+			{
+				Name:     "android-legacy-launcher-icon",
+				Dir:      launcherDir,
+				FileName: "ic_launcher",
+				BaseSize: legacyLauncherBaseSize,
+				Sizes:    legacyLauncherSizes,
+				Shape:    TargetShapeCircle,
+				Padding:  &legacyLauncherPadding,
+			},
+			{
+				Name:       "android-adaptive-icon-background",
+				Dir:        launcherDir,
+				FileName:   "ic_launcher_background",
+				BaseSize:   adaptiveLayerBaseSize,
+				Sizes:      adaptiveLayerSizes,
+				Background: TargetBackgroundOnly,
+				Manifest: &TargetManifest{
+					Path: path.Join(PlatformTypeAndroid, "res", "mipmap-anydpi-v26", "ic_launcher.xml"),
+					Template: `<?xml version="1.0" encoding="utf-8" ?>
+<adaptive-icon xmlns:android="http://schemas.android.com/apk/res/android">
+    <background android:drawable="@mipmap/ic_launcher_background" />
+    <foreground android:drawable="@mipmap/ic_launcher_foreground" />
+    <monochrome android:drawable="@mipmap/ic_launcher_monochrome" />
+</adaptive-icon>
+`,
+				},
+			},
+			{
+				Name:       "android-adaptive-icon-foreground",
+				Dir:        launcherDir,
+				FileName:   "ic_launcher_foreground",
+				BaseSize:   adaptiveLayerBaseSize,
+				Sizes:      adaptiveLayerSizes,
+				Background: TargetBackgroundTransparent,
+				Padding:    &adaptiveLogoPadding,
+			},
+			{
+				Name:       "android-adaptive-icon-monochrome",
+				Dir:        launcherDir,
+				FileName:   "ic_launcher_monochrome",
+				BaseSize:   adaptiveLayerBaseSize,
+				Sizes:      adaptiveLayerSizes,
+				Background: TargetBackgroundTransparent,
+				Padding:    &adaptiveLogoPadding,
+			},
+			{
+				Name:       "android-notification-icon",
+				Dir:        launcherDir,
+				FileName:   "ic_stat_notification_icon",
+				BaseSize:   notificationBaseSize,
+				Sizes:      notificationSizes,
+				Background: TargetBackgroundTransparent,
+				Tint:       "#FFFFFF",
+				Padding:    &noPadding,
+			},
+		},
+	}
+}
+
+// The density scales of the Android launcher dpis of [baseSize]
+func androidLauncherTargetSizes(dpis []asset, baseSize int) []TargetSize {
+	sizes := make([]TargetSize, len(dpis))
+	for i, v := range dpis {
+		dpi := v.(androidAppIconDpiAsset)
+		sizes[i] = TargetSize{Label: dpi.dpiName, Scale: float64(dpi.size) / float64(baseSize)}
+	}
+	return sizes
+}
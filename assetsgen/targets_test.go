@@ -0,0 +1,167 @@
+package assetsgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"maps"
+	"path"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+// The builtin description must write the same files as the generators, so a change to [iosAppIconDpis]
+// or the android dpis tables is caught here
+func TestBuiltinTargetsDescriptionMatchesGenerators(t *testing.T) {
+	src, err := NewSourceImage(solidNRGBA(256, 256, color.NRGBA{R: 51, G: 102, B: 204, A: 255}), "logo.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// not a solid color, the adaptive background is an image then
+	bgIcon := NewLinearGradientBackground(GradientTable{{Col: colorful.Color{R: 1, G: 1, B: 1}}, {Col: colorful.Color{B: 1}, Pos: 1}}, 90)
+	resize := DefaultResizeOptions()
+
+	fromSpec := NewMemorySink()
+	err = GenerateTargetsFromSource(src, BuiltinTargetsDescription(), TargetOptions{
+		AlphaThreshold: 0.5,
+		BgIcon:         bgIcon,
+		Resize:         resize,
+		Output:         fromSpec,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fromGenerators := NewMemorySink()
+	err = GenerateAppIconForIosFromSource(src, IosAppIconOptions{
+		BgIcon:         bgIcon,
+		AlphaThreshold: 0.5,
+		Resize:         resize,
+		Output:         fromGenerators,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = GenerateAppIconForAndroidFromSource(src, AndroidAppIconOptions{
+		RoundedCornerPercentRadius: 1,
+		AlphaThreshold:             0.5,
+		BgIcon:                     bgIcon,
+		FolderName:                 AndroidFolderMipmap,
+		OutputFileName:             "ic_launcher",
+		Resize:                     resize,
+		Output:                     fromGenerators,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = GenerateAndroidGooglePlayLogoFromSource(src, AndroidGooglePlayLogoOptions{
+		BgIcon:         bgIcon,
+		AlphaThreshold: 0.5,
+		OutputFileName: "play_store_logo_512x512",
+		Resize:         resize,
+		Output:         fromGenerators,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = GenerateNotificationIconForAndroidFromSource(src, AndroidNotificationIconOptions{
+		FolderName:     AndroidFolderMipmap,
+		AlphaThreshold: 0.5,
+		OutputFileName: "ic_stat_notification_icon",
+		Resize:         resize,
+		Output:         fromGenerators,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, want := fromSpec.Files(), fromGenerators.Files()
+	gotNames, wantNames := slices.Sorted(maps.Keys(got)), slices.Sorted(maps.Keys(want))
+	if !slices.Equal(gotNames, wantNames) {
+		t.Fatalf("the spec writes\n%v\nthe generators write\n%v", gotNames, wantNames)
+	}
+
+	for _, name := range wantNames {
+		if path.Ext(name) == ".xml" {
+			if g, w := string(got[name]), string(want[name]); g != w {
+				t.Errorf("%s of the spec is\n%s\nthe generator's is\n%s", name, g, w)
+			}
+			continue
+		}
+		if path.Ext(name) == ".json" {
+			if g, w := contentsJsonEntries(t, got[name]), contentsJsonEntries(t, want[name]); !reflect.DeepEqual(g, w) {
+				t.Errorf("%s of the spec has\n%v\nthe generator has\n%v", name, g, w)
+			}
+			continue
+		}
+
+		g, _, err := image.DecodeConfig(bytes.NewReader(got[name]))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		w, _, err := image.DecodeConfig(bytes.NewReader(want[name]))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if g.Width != w.Width || g.Height != w.Height {
+			t.Errorf("%s of the spec is %dx%d, the generator's is %dx%d", name, g.Width, g.Height, w.Width, w.Height)
+		}
+	}
+}
+
+func contentsJsonEntries(t *testing.T, data []byte) []iosContentsJsonImage {
+	t.Helper()
+	var contents iosContentsJson
+	err := json.Unmarshal(data, &contents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return contents.Images
+}
+
+func TestTargetManifestJsonEscaping(t *testing.T) {
+	src, err := NewSourceImage(solidNRGBA(64, 64, color.NRGBA{R: 51, G: 102, B: 204, A: 255}), "logo.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	note := `a "quoted" \ note` + "\n<tab>\t"
+	spec := TargetSpec{Targets: []Target{{
+		Name:     "store",
+		Dir:      "store",
+		FileName: `icon "{{.Label}}"`,
+		Sizes:    []TargetSize{{Label: `2"x`, Size: 16, Meta: map[string]string{"note": note}}},
+		Manifest: &TargetManifest{
+			Path:     "store/manifest.json",
+			Template: `[{{range .Files}}{"filename":{{json .FileName}},"note":{{json .Meta.note}}}{{end}}]`,
+		},
+	}}}
+
+	output := NewMemorySink()
+	err = GenerateTargetsFromSource(src, spec, TargetOptions{
+		AlphaThreshold: 0.5,
+		BgIcon:         NewSolidColorBackground(colorful.Color{R: 1, G: 1, B: 1}),
+		Resize:         DefaultResizeOptions(),
+		Output:         output,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, ok := output.File("store/manifest.json")
+	if !ok {
+		t.Fatal("the manifest was not written")
+	}
+	var got []map[string]string
+	err = json.Unmarshal(data, &got)
+	if err != nil {
+		t.Fatalf("the manifest is not valid JSON: %v\n%s", err, data)
+	}
+	want := []map[string]string{{"filename": `icon "2"x".png`, "note": note}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("the manifest has %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,376 @@
+package assetsgen
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+// How the background around the logo is found, e.g: a JPG logo on white. The fully transparent pixels are always background
+type TrimOptions struct {
+	// The solid background color of the source. nil to detect it when [TrimOptions.DetectBorderColor] is set, or to trim the transparent pixels only
+	BorderColor *colorful.Color
+
+	// Detects the background color from the edges of the image, used when [TrimOptions.BorderColor] is nil.
+	// Falls back to the transparent pixels only if the edges are mostly transparent or have no dominant color
+	DetectBorderColor bool
+
+	// Between [0..1] how far a color can be from the border color and still count as background.
+	// 0 is an exact match, 0.1 is enough for the JPG compression noise
+	Tolerance float64
+
+	// Makes the background pixels connected to the edges of the image transparent, so the logo can be masked or
+	// used as an adaptive icon foreground. The background inside the logo (e.g: white letters) is kept
+	KeyOut bool
+
+	// Between [0..1] the pixels with alpha at or below it are trimmed like the fully transparent ones,
+	// e.g: 0.05 ignores the anti aliasing noise and faint shadows. 0 trims the fully transparent pixels only
+	AlphaThreshold float64
+
+	// Between [0..1] trims to the smallest box that holds this share of the visible content (weighted by alpha),
+	// so a few stray pixels far from the logo don't stop the trim. 0 or 1 trims to the exact content bounds
+	Coverage float64
+}
+
+func (o TrimOptions) key() string {
+	bc := "none"
+	if o.BorderColor != nil {
+		bc = o.BorderColor.Hex()
+	}
+	return fmt.Sprint(
+		"border:", bc, " detect:", o.DetectBorderColor, " tolerance:", o.Tolerance, " key-out:", o.KeyOut,
+		" alpha:", o.AlphaThreshold, " coverage:", o.Coverage,
+	)
+}
+
+// The border color to trim and key out, nil to use the transparent pixels only
+func (o TrimOptions) borderColorFor(img *image.NRGBA) *colorful.Color {
+	if o.BorderColor != nil {
+		return o.BorderColor
+	}
+	if o.DetectBorderColor {
+		if c, ok := detectBorderColor(img); ok {
+			return &c
+		}
+	}
+	return nil
+}
+
+// The distance between two colors in [0..1], 0 is the same color and 1 is black to white
+func rgbDistance(px []uint8, c [3]float64) float64 {
+	dr := float64(px[0]) - c[0]
+	dg := float64(px[1]) - c[1]
+	db := float64(px[2]) - c[2]
+	return math.Sqrt(dr*dr+dg*dg+db*db) / (255 * math.Sqrt(3))
+}
+
+func colorTo255(c colorful.Color) [3]float64 {
+	r, g, b := c.Clamped().RGB255()
+	return [3]float64{float64(r), float64(g), float64(b)}
+}
+
+// Returns a predicate that reports whether a pixel (R, G, B, A not premultiplied) is background.
+// The pixels with alpha at or below [alphaThreshold] (between [0..1]) are background
+func backgroundMatcher(borderColor *colorful.Color, tolerance float64, alphaThreshold float64) func(px []uint8) bool {
+	maxBgAlpha := uint8(min(max(alphaThreshold, 0), 1) * 255)
+	if borderColor == nil {
+		return func(px []uint8) bool { return px[3] <= maxBgAlpha }
+	}
+
+	bc := colorTo255(*borderColor)
+	minAlpha := uint8(255 * (1 - tolerance))
+	return func(px []uint8) bool {
+		return px[3] <= maxBgAlpha || (px[3] >= minAlpha && rgbDistance(px, bc) <= tolerance)
+	}
+}
+
+// The share of the edge pixels that should have the dominant color for it to be used as the border color
+const borderColorMinShare = 0.5
+
+// Finds the dominant color of the edge pixels. The colors are bucketed by their 4 high bits per channel
+// so the JPG noise doesn't split the votes, the result is the average of the pixels of the winning bucket.
+func detectBorderColor(img *image.NRGBA) (colorful.Color, bool) {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	if w == 0 || h == 0 {
+		return colorful.Color{}, false
+	}
+
+	type bucket struct {
+		count   int
+		r, g, b int
+	}
+	buckets := make(map[uint16]*bucket)
+	var total, transparent int
+
+	visit := func(x, y int) {
+		i := y*img.Stride + x*4
+		px := img.Pix[i : i+4]
+		total++
+		if px[3] < 128 {
+			transparent++
+			return
+		}
+
+		k := uint16(px[0]>>4)<<8 | uint16(px[1]>>4)<<4 | uint16(px[2]>>4)
+		bk, ok := buckets[k]
+		if !ok {
+			bk = &bucket{}
+			buckets[k] = bk
+		}
+		bk.count++
+		bk.r += int(px[0])
+		bk.g += int(px[1])
+		bk.b += int(px[2])
+	}
+
+	for x := range w {
+		visit(x, 0)
+		if h > 1 {
+			visit(x, h-1)
+		}
+	}
+	for y := 1; y < h-1; y++ {
+		visit(0, y)
+		if w > 1 {
+			visit(w-1, y)
+		}
+	}
+
+	if transparent*2 >= total {
+		return colorful.Color{}, false
+	}
+
+	var best *bucket
+	for _, bk := range buckets {
+		if best == nil || bk.count > best.count {
+			best = bk
+		}
+	}
+	if float64(best.count) < float64(total)*borderColorMinShare {
+		return colorful.Color{}, false
+	}
+
+	return colorful.Color{
+		R: float64(best.r) / float64(best.count) / 255,
+		G: float64(best.g) / float64(best.count) / 255,
+		B: float64(best.b) / float64(best.count) / 255,
+	}, true
+}
+
+// Makes the background pixels that are connected to the edges of the image transparent.
+// The pixels bordering the removed background get a soft alpha by how close they are to the background color compared
+// to the logo color next to them, and the background color is taken out of them, so the anti aliased edges don't keep
+// a halo of the old background.
+func keyOutBackground(img *image.NRGBA, borderColor colorful.Color, tolerance float64) *image.NRGBA {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	isBackground := backgroundMatcher(&borderColor, tolerance, 0)
+	bc := colorTo255(borderColor)
+
+	pxAt := func(i int) []uint8 {
+		x, y := i%w, i/w
+		o := y*img.Stride + x*4
+		return img.Pix[o : o+4 : o+4]
+	}
+
+	// flood fill from the edges
+	keyed := make([]bool, w*h)
+	queue := make([]int, 0, 2*(w+h))
+	push := func(i int) {
+		if !keyed[i] && isBackground(pxAt(i)) {
+			keyed[i] = true
+			queue = append(queue, i)
+		}
+	}
+	for x := range w {
+		push(x)
+		push((h-1)*w + x)
+	}
+	for y := range h {
+		push(y * w)
+		push(y*w + w - 1)
+	}
+	for len(queue) > 0 {
+		i := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		x, y := i%w, i/w
+		if x > 0 {
+			push(i - 1)
+		}
+		if x < w-1 {
+			push(i + 1)
+		}
+		if y > 0 {
+			push(i - w)
+		}
+		if y < h-1 {
+			push(i + w)
+		}
+	}
+
+	isKeyed := func(x, y int) bool {
+		return x >= 0 && y >= 0 && x < w && y < h && keyed[y*w+x]
+	}
+
+	return mapNRGBA(img, func(x, y int, srcPx, dstPx []uint8) {
+		if keyed[y*w+x] {
+			return
+		}
+		copy(dstPx, srcPx)
+
+		touchesKeyed := false
+		for ny := y - 1; ny <= y+1 && !touchesKeyed; ny++ {
+			for nx := x - 1; nx <= x+1; nx++ {
+				if isKeyed(nx, ny) {
+					touchesKeyed = true
+					break
+				}
+			}
+		}
+		if !touchesKeyed {
+			return
+		}
+
+		// the most distinct color around the pixel is taken as the solid color of the logo
+		fgDistance := 0.0
+		for ny := max(y-2, 0); ny <= min(y+2, h-1); ny++ {
+			for nx := max(x-2, 0); nx <= min(x+2, w-1); nx++ {
+				if !keyed[ny*w+nx] {
+					fgDistance = max(fgDistance, rgbDistance(pxAt(ny*w+nx), bc))
+				}
+			}
+		}
+
+		d := rgbDistance(srcPx, bc)
+		if fgDistance <= tolerance || d >= fgDistance {
+			return
+		}
+
+		// un-mix the background: px = a*fg + (1-a)*bg
+		a := min(max(d/fgDistance, 1.0/255), 1)
+		for c := range 3 {
+			fg := (float64(srcPx[c]) - (1-a)*bc[c]) / a
+			dstPx[c] = uint8(min(max(fg, 0), 255) + 0.5)
+		}
+		dstPx[3] = uint8(a*float64(srcPx[3]) + 0.5)
+	})
+}
+
+// Returns the bounds of the content of the image, the pixels that are not background per [TrimOptions].
+// ok is false if the image is all background. The key out doesn't change the bounds, it only removes the pixels the trim drops anyway
+func DetectContentRect(img image.Image, options TrimOptions) (rect image.Rectangle, ok bool) {
+	nrgba := asNRGBA(img)
+	isBackground := backgroundMatcher(options.borderColorFor(nrgba), options.Tolerance, options.AlphaThreshold)
+	rect, ok = contentRect(nrgba, isBackground, options.Coverage)
+	return rect.Add(img.Bounds().Min), ok
+}
+
+// Scans every row and column (the alpha of the content pixels summed per row and per column), and trims the
+// edges that hold no content. With [coverage] in (0..1) the edges may also drop up to half of the remaining
+// (1-coverage) share of the content each side.
+func contentRect(img *image.NRGBA, isBackground func(px []uint8) bool, coverage float64) (image.Rectangle, bool) {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+
+	mass := func(x, y int) float64 {
+		i := y*img.Stride + x*4
+		px := img.Pix[i : i+4 : i+4]
+		if isBackground(px) {
+			return 0
+		}
+		return float64(px[3])
+	}
+
+	rowMass := make([]float64, h)
+	parallelRows(h, func(y int) {
+		for x := range w {
+			rowMass[y] += mass(x, y)
+		}
+	})
+
+	colMass := make([]float64, w)
+	parallelRows(w, func(x int) {
+		for y := range h {
+			colMass[x] += mass(x, y)
+		}
+	})
+
+	total := 0.0
+	for _, m := range rowMass {
+		total += m
+	}
+	if total == 0 {
+		return image.Rectangle{}, false
+	}
+
+	drop := 0.0
+	if coverage > 0 && coverage < 1 {
+		drop = total * (1 - coverage) / 2
+	}
+
+	// the number of entries from the start of the projection whose mass sum stays within drop (and is zero for the exact bounds)
+	trimmable := func(projection []float64, reverse bool) int {
+		sum := 0.0
+		for i := range projection {
+			m := projection[i]
+			if reverse {
+				m = projection[len(projection)-1-i]
+			}
+			sum += m
+			if m > 0 && sum > drop {
+				return i
+			}
+		}
+		return len(projection)
+	}
+
+	rect := image.Rect(trimmable(colMass, false), trimmable(rowMass, false), w-trimmable(colMass, true), h-trimmable(rowMass, true))
+	if rect.Empty() {
+		return image.Rectangle{}, false
+	}
+	return rect, true
+}
+
+// Makes the background around the logo transparent, see [TrimOptions.KeyOut].
+// Does nothing if there is no border color to key out
+func (imgInfo *imageInfo) KeyOutBackground(options TrimOptions) *imageInfo {
+	img := asNRGBA(imgInfo.img)
+	borderColor := options.borderColorFor(img)
+	if borderColor == nil {
+		return imgInfo
+	}
+
+	imgInfo.img = keyOutBackground(img, *borderColor, options.Tolerance)
+	return imgInfo
+}
+
+// The offset from the center of the content bounds to the alpha weighted centroid of the content, (0,0) for an empty image
+func opticalCenterOffset(img *image.NRGBA) (dx, dy float64) {
+	rect, ok := contentRect(img, backgroundMatcher(nil, 0, 0), 0)
+	if !ok {
+		return 0, 0
+	}
+
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	rowMass := make([]float64, h)
+	rowMassX := make([]float64, h)
+	parallelRows(h, func(y int) {
+		row := img.Pix[y*img.Stride : y*img.Stride+w*4]
+		for x := range w {
+			a := float64(row[x*4+3])
+			rowMass[y] += a
+			rowMassX[y] += a * (float64(x) + 0.5)
+		}
+	})
+
+	var total, sumX, sumY float64
+	for y := range h {
+		total += rowMass[y]
+		sumX += rowMassX[y]
+		sumY += rowMass[y] * (float64(y) + 0.5)
+	}
+
+	cx := float64(rect.Min.X+rect.Max.X) / 2
+	cy := float64(rect.Min.Y+rect.Max.Y) / 2
+	return sumX/total - cx, sumY/total - cy
+}
@@ -0,0 +1,212 @@
+package assetsgen
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+var (
+	white = color.NRGBA{255, 255, 255, 255}
+	red   = color.NRGBA{255, 0, 0, 255}
+)
+
+// A JPG like white background, the pixels are off by up to 3 from white by a fixed pattern
+func noisyWhiteNRGBA(w, h int) *image.NRGBA {
+	img := solidNRGBA(w, h, white)
+	for y := range h {
+		for x := range w {
+			n := uint8((x*7 + y*3) % 4)
+			img.SetNRGBA(x, y, color.NRGBA{255 - n, 255 - n/2, 255 - n, 255})
+		}
+	}
+	return img
+}
+
+func fillRect(img *image.NRGBA, r image.Rectangle, c color.NRGBA) *image.NRGBA {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestDetectBorderColor(t *testing.T) {
+	tests := []struct {
+		name   string
+		img    *image.NRGBA
+		want   colorful.Color
+		wantOk bool
+	}{
+		{
+			name:   "white jpg border",
+			img:    fillRect(noisyWhiteNRGBA(10, 10), image.Rect(3, 3, 7, 7), red),
+			want:   colorful.Color{R: 1, G: 1, B: 1},
+			wantOk: true,
+		},
+		{
+			name: "transparent border",
+			img:  fillRect(image.NewNRGBA(image.Rect(0, 0, 10, 10)), image.Rect(3, 3, 7, 7), red),
+		},
+		{
+			name: "no dominant color",
+			img: fillRect(
+				fillRect(solidNRGBA(10, 10, red), image.Rect(0, 0, 10, 4), white),
+				image.Rect(0, 6, 10, 10),
+				color.NRGBA{0, 0, 255, 255},
+			),
+		},
+		{
+			name:   "uniform",
+			img:    solidNRGBA(4, 4, red),
+			want:   colorful.Color{R: 1},
+			wantOk: true,
+		},
+		{
+			name:   "single pixel",
+			img:    solidNRGBA(1, 1, red),
+			want:   colorful.Color{R: 1},
+			wantOk: true,
+		},
+		{
+			name: "empty",
+			img:  image.NewNRGBA(image.Rect(0, 0, 0, 0)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := detectBorderColor(tt.img)
+			if ok != tt.wantOk {
+				t.Fatalf("detectBorderColor() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if ok && got.DistanceRgb(tt.want) > 0.02 {
+				t.Errorf("detectBorderColor() = %v, want %v", got.Hex(), tt.want.Hex())
+			}
+		})
+	}
+}
+
+func TestDetectContentRect(t *testing.T) {
+	whiteColor := colorful.Color{R: 1, G: 1, B: 1}
+	// 25 from white, a distance of ~0.098
+	lightGray := color.NRGBA{230, 230, 230, 255}
+
+	tests := []struct {
+		name    string
+		img     *image.NRGBA
+		options TrimOptions
+		want    image.Rectangle
+		wantOk  bool
+	}{
+		{
+			name:    "white jpg border detected",
+			img:     fillRect(noisyWhiteNRGBA(10, 10), image.Rect(3, 3, 7, 7), red),
+			options: TrimOptions{DetectBorderColor: true, Tolerance: 0.05},
+			want:    image.Rect(3, 3, 7, 7),
+			wantOk:  true,
+		},
+		{
+			name:    "white jpg border without tolerance",
+			img:     fillRect(noisyWhiteNRGBA(10, 10), image.Rect(3, 3, 7, 7), red),
+			options: TrimOptions{DetectBorderColor: true},
+			want:    image.Rect(0, 0, 10, 10),
+			wantOk:  true,
+		},
+		{
+			name:   "transparent only",
+			img:    fillRect(image.NewNRGBA(image.Rect(0, 0, 10, 10)), image.Rect(2, 4, 5, 9), red),
+			want:   image.Rect(2, 4, 5, 9),
+			wantOk: true,
+		},
+		{
+			name:    "within the tolerance",
+			img:     fillRect(fillRect(solidNRGBA(10, 10, white), image.Rect(1, 1, 2, 2), lightGray), image.Rect(4, 4, 7, 7), red),
+			options: TrimOptions{BorderColor: &whiteColor, Tolerance: 0.1},
+			want:    image.Rect(4, 4, 7, 7),
+			wantOk:  true,
+		},
+		{
+			name:    "just over the tolerance",
+			img:     fillRect(fillRect(solidNRGBA(10, 10, white), image.Rect(1, 1, 2, 2), lightGray), image.Rect(4, 4, 7, 7), red),
+			options: TrimOptions{BorderColor: &whiteColor, Tolerance: 0.09},
+			want:    image.Rect(1, 1, 7, 7),
+			wantOk:  true,
+		},
+		{
+			name:    "faint pixels under the alpha threshold",
+			img:     fillRect(fillRect(image.NewNRGBA(image.Rect(0, 0, 10, 10)), image.Rect(0, 0, 1, 1), color.NRGBA{0, 0, 0, 10}), image.Rect(4, 4, 6, 6), red),
+			options: TrimOptions{AlphaThreshold: 0.05},
+			want:    image.Rect(4, 4, 6, 6),
+			wantOk:  true,
+		},
+		{
+			name:    "a stray pixel dropped by the coverage",
+			img:     fillRect(fillRect(image.NewNRGBA(image.Rect(0, 0, 20, 20)), image.Rect(19, 19, 20, 20), red), image.Rect(5, 5, 15, 15), red),
+			options: TrimOptions{Coverage: 0.97},
+			want:    image.Rect(5, 5, 15, 15),
+			wantOk:  true,
+		},
+		{
+			name:    "uniform color",
+			img:     solidNRGBA(8, 8, white),
+			options: TrimOptions{DetectBorderColor: true},
+		},
+		{
+			name: "fully transparent",
+			img:  image.NewNRGBA(image.Rect(0, 0, 8, 8)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := DetectContentRect(tt.img, tt.options)
+			if ok != tt.wantOk {
+				t.Fatalf("DetectContentRect() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if ok && got != tt.want {
+				t.Errorf("DetectContentRect() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyOutBackground(t *testing.T) {
+	// a red square with an anti aliased left edge (half red, half white) and a white hole in the middle
+	img := fillRect(solidNRGBA(11, 11, white), image.Rect(3, 3, 8, 8), red)
+	fillRect(img, image.Rect(2, 3, 3, 8), color.NRGBA{255, 128, 128, 255})
+	fillRect(img, image.Rect(5, 5, 6, 6), white)
+
+	got := keyOutBackground(img, colorful.Color{R: 1, G: 1, B: 1}, 0.05)
+
+	tests := []struct {
+		name string
+		x, y int
+		// the alpha and the colors should be within 8 of it
+		want color.NRGBA
+	}{
+		{name: "background", x: 0, y: 0, want: color.NRGBA{}},
+		{name: "background next to the logo", x: 1, y: 5, want: color.NRGBA{}},
+		{name: "anti aliased edge", x: 2, y: 5, want: color.NRGBA{255, 0, 0, 127}},
+		{name: "logo", x: 4, y: 4, want: red},
+		{name: "enclosed background", x: 5, y: 5, want: white},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := got.NRGBAAt(tt.x, tt.y)
+			if tt.want.A == 0 {
+				if c.A != 0 {
+					t.Errorf("(%d,%d) = %v, want transparent", tt.x, tt.y, c)
+				}
+				return
+			}
+			if absDiff(c.A, tt.want.A) > 8 || absDiff(c.R, tt.want.R) > 8 || absDiff(c.G, tt.want.G) > 8 || absDiff(c.B, tt.want.B) > 8 {
+				t.Errorf("(%d,%d) = %v, want about %v", tt.x, tt.y, c, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package assetsgen
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+type VerifyIssueKind string
+
+const (
+	// The file differs from the regenerated one
+	VerifyIssueStale VerifyIssueKind = "stale"
+	// The file is generated but not in the project
+	VerifyIssueMissing VerifyIssueKind = "missing"
+	// The file is in the project where the generated files are, but isn't generated
+	VerifyIssueExtra VerifyIssueKind = "extra"
+)
+
+type VerifyIssue struct {
+	Kind VerifyIssueKind
+
+	// The path of the file in the project
+	Path string
+
+	// Why the file is stale, empty for the other kinds
+	Detail string
+}
+
+func (i VerifyIssue) String() string {
+	if i.Detail == "" {
+		return fmt.Sprint(i.Kind, ": ", i.Path)
+	}
+	return fmt.Sprint(i.Kind, ": ", i.Path, " (", i.Detail, ")")
+}
+
+type VerifyOptions struct {
+	// The max CIE76 ΔE between two pixels to be seen as the same, composited on black and on white.
+	// Around 2.3 is just noticeable. Zero falls back to [DefaultVerifyTolerance]
+	Tolerance float64
+
+	// Every file of the project folder should be generated, the others are extra. e.g: AppIcon.appiconset.
+	// Otherwise only the files named like a generated one in the same kind of folder are extra, e.g: mipmap-ldpi/ic_launcher.png
+	// or mipmap-xxhdpi/ic_launcher.webp when mipmap-xxhdpi/ic_launcher.png is generated
+	Owned bool
+
+	// The files of the project matching one of these patterns (see [path.Match]) are extra if they are not generated
+	ExtraPatterns []string
+}
+
+const DefaultVerifyTolerance = 2.3
+
+// Compares the files generated in [expectedDir] with the files of the project in [projectDir].
+// The images are compared pixel by pixel with a perceptual tolerance, the other files as text.
+// The issues are sorted by path
+func VerifyDir(expectedDir, projectDir string, options VerifyOptions) ([]VerifyIssue, error) {
+	if options.Tolerance <= 0 {
+		options.Tolerance = DefaultVerifyTolerance
+	}
+
+	expected, err := listFiles(os.DirFS(expectedDir), func(string) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+
+	generatedNames := map[string]bool{}
+	generatedFolderKinds := map[string]bool{}
+	for _, name := range expected {
+		generatedNames[folderKindAndStem(name)] = true
+		generatedFolderKinds[folderKind(path.Dir(name))] = true
+	}
+
+	// only the kinds of folders the files are generated in, e.g: not the java sources next to the play store logo
+	project, err := listFiles(os.DirFS(projectDir), func(dir string) bool {
+		return options.Owned || generatedFolderKinds[folderKind(dir)]
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	var issues []VerifyIssue
+	for _, name := range expected {
+		if _, found := slices.BinarySearch(project, name); !found {
+			issues = append(issues, VerifyIssue{Kind: VerifyIssueMissing, Path: filepath.Join(projectDir, name)})
+			continue
+		}
+
+		detail, err := compareFiles(filepath.Join(expectedDir, name), filepath.Join(projectDir, name), options.Tolerance)
+		if err != nil {
+			return nil, err
+		}
+		if detail != "" {
+			issues = append(issues, VerifyIssue{Kind: VerifyIssueStale, Path: filepath.Join(projectDir, name), Detail: detail})
+		}
+	}
+
+	for _, name := range project {
+		if _, found := slices.BinarySearch(expected, name); found {
+			continue
+		}
+		if options.Owned || generatedNames[folderKindAndStem(name)] || matchesAny(name, options.ExtraPatterns) {
+			issues = append(issues, VerifyIssue{Kind: VerifyIssueExtra, Path: filepath.Join(projectDir, name)})
+		}
+	}
+
+	slices.SortFunc(issues, func(a, b VerifyIssue) int { return strings.Compare(a.Path, b.Path) })
+	return issues, nil
+}
+
+// The sorted slash separated paths of the regular files, in the folders [walkDir] allows
+func listFiles(fsys fs.FS, walkDir func(dir string) bool) ([]string, error) {
+	var files []string
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && p != "." && !walkDir(p) {
+			return fs.SkipDir
+		}
+		if d.Type().IsRegular() {
+			files = append(files, p)
+		}
+		return nil
+	})
+	// the walk order isn't the string order, e.g: values/ is walked before values-night/
+	slices.Sort(files)
+	return files, err
+}
+
+// The first folder of the path without its qualifiers, e.g: "mipmap" for "mipmap-xxhdpi" and "." for the root
+func folderKind(dir string) string {
+	first, _, _ := strings.Cut(dir, "/")
+	kind, _, _ := strings.Cut(first, "-")
+	return kind
+}
+
+// e.g: "mipmap/ic_launcher" for "mipmap-xxhdpi/ic_launcher.png"
+func folderKindAndStem(name string) string {
+	dir, file := path.Split(name)
+	return path.Join(folderKind(path.Clean(dir)), strings.TrimSuffix(file, path.Ext(file)))
+}
+
+func matchesAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns why the files differ, empty if they are the same
+func compareFiles(expectedPath, projectPath string, tolerance float64) (string, error) {
+	expected, err := os.ReadFile(expectedPath)
+	if err != nil {
+		return "", err
+	}
+	project, err := os.ReadFile(projectPath)
+	if err != nil {
+		return "", err
+	}
+
+	if bytes.Equal(expected, project) {
+		return "", nil
+	}
+
+	switch strings.ToLower(path.Ext(expectedPath)) {
+	case ".png", ".jpg", ".jpeg":
+		return compareImages(expected, project, tolerance)
+	default:
+		if normalizeText(expected) == normalizeText(project) {
+			return "", nil
+		}
+		return "content differs", nil
+	}
+}
+
+// Ignores the line endings and the whitespace around the content
+func normalizeText(b []byte) string {
+	return strings.TrimSpace(strings.ReplaceAll(string(b), "\r\n", "\n"))
+}
+
+func compareImages(expectedData, projectData []byte, tolerance float64) (string, error) {
+	expectedImg, _, err := image.Decode(bytes.NewReader(expectedData))
+	if err != nil {
+		return "", err
+	}
+	projectImg, _, err := image.Decode(bytes.NewReader(projectData))
+	if err != nil {
+		return fmt.Sprint("can't decode the image: ", err), nil
+	}
+
+	a, b := asNRGBA(expectedImg), asNRGBA(projectImg)
+	if a.Rect.Size() != b.Rect.Size() {
+		return fmt.Sprintf("%dx%d, expected %dx%d", b.Rect.Dx(), b.Rect.Dy(), a.Rect.Dx(), a.Rect.Dy()), nil
+	}
+
+	differs, maxDelta := 0, 0.0
+	for i := 0; i < len(a.Pix); i += 4 {
+		pa, pb := a.Pix[i:i+4:i+4], b.Pix[i:i+4:i+4]
+		if bytes.Equal(pa, pb) {
+			continue
+		}
+
+		delta := pixelDeltaE(pa, pb)
+		maxDelta = max(maxDelta, delta)
+		if delta > tolerance {
+			differs++
+		}
+	}
+
+	if differs == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("%d pixels differ, max ΔE %.1f", differs, maxDelta), nil
+}
+
+// The CIE76 ΔE of two not premultiplied pixels composited on black and on white, the bigger of the two.
+// Compositing keeps the difference of the alpha visible, but ignores the color of the fully transparent pixels
+func pixelDeltaE(a, b []uint8) float64 {
+	delta := 0.0
+	for _, bg := range []float64{0, 1} {
+		ca := compositeOn(a, bg)
+		cb := compositeOn(b, bg)
+		delta = max(delta, ca.DistanceLab(cb)*100)
+	}
+	return delta
+}
+
+func compositeOn(px []uint8, bg float64) colorful.Color {
+	alpha := float64(px[3]) / 255
+	channel := func(v uint8) float64 { return float64(v)/255*alpha + bg*(1-alpha) }
+	return colorful.Color{R: channel(px[0]), G: channel(px[1]), B: channel(px[2])}
+}
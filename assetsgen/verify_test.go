@@ -0,0 +1,201 @@
+package assetsgen
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func pngBytes(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func writeFiles(t *testing.T, dir string, files map[string][]byte) {
+	t.Helper()
+	for name, data := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(p), os.ModePerm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(p, data, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestVerifyDir(t *testing.T) {
+	icon := pngBytes(t, solidNRGBA(4, 4, color.NRGBA{200, 40, 40, 255}))
+	expected := map[string][]byte{
+		"mipmap-mdpi/ic_launcher.png": icon,
+		"values/colors.xml":           []byte("<resources>\n</resources>\n"),
+	}
+
+	// the project files on top of a copy of the expected ones, nil deletes the file
+	tests := []struct {
+		name    string
+		project map[string][]byte
+		options VerifyOptions
+		want    []string
+	}{
+		{
+			name: "up to date",
+		},
+		{
+			name:    "missing",
+			project: map[string][]byte{"mipmap-mdpi/ic_launcher.png": nil},
+			want:    []string{"missing: mipmap-mdpi/ic_launcher.png"},
+		},
+		{
+			name:    "within the tolerance",
+			project: map[string][]byte{"mipmap-mdpi/ic_launcher.png": pngBytes(t, solidNRGBA(4, 4, color.NRGBA{201, 40, 40, 255}))},
+		},
+		{
+			name:    "made transparent",
+			project: map[string][]byte{"mipmap-mdpi/ic_launcher.png": pngBytes(t, solidNRGBA(4, 4, color.NRGBA{0, 0, 255, 0}))},
+			want:    []string{"stale: mipmap-mdpi/ic_launcher.png"},
+		},
+		{
+			name:    "over the tolerance",
+			project: map[string][]byte{"mipmap-mdpi/ic_launcher.png": pngBytes(t, solidNRGBA(4, 4, color.NRGBA{40, 40, 200, 255}))},
+			want:    []string{"stale: mipmap-mdpi/ic_launcher.png"},
+		},
+		{
+			name:    "over a raised tolerance",
+			project: map[string][]byte{"mipmap-mdpi/ic_launcher.png": pngBytes(t, solidNRGBA(4, 4, color.NRGBA{40, 40, 200, 255}))},
+			options: VerifyOptions{Tolerance: 1000},
+		},
+		{
+			name:    "other size",
+			project: map[string][]byte{"mipmap-mdpi/ic_launcher.png": pngBytes(t, solidNRGBA(5, 5, color.NRGBA{200, 40, 40, 255}))},
+			want:    []string{"stale: mipmap-mdpi/ic_launcher.png"},
+		},
+		{
+			name:    "text with other line endings",
+			project: map[string][]byte{"values/colors.xml": []byte("<resources>\r\n</resources>")},
+		},
+		{
+			name:    "text differs",
+			project: map[string][]byte{"values/colors.xml": []byte("<resources/>")},
+			want:    []string{"stale: values/colors.xml"},
+		},
+		{
+			name: "extra",
+			project: map[string][]byte{
+				// named like a generated file
+				"mipmap-ldpi/ic_launcher.png":    icon,
+				"mipmap-xxhdpi/ic_launcher.webp": icon,
+				// not generated files of the project
+				"mipmap-mdpi/ic_other.png": icon,
+				"java/Main.java":           []byte("class Main {}"),
+			},
+			want: []string{"extra: mipmap-ldpi/ic_launcher.png", "extra: mipmap-xxhdpi/ic_launcher.webp"},
+		},
+		{
+			name: "owned",
+			project: map[string][]byte{
+				"mipmap-mdpi/ic_other.png": icon,
+				"java/Main.java":           []byte("class Main {}"),
+			},
+			options: VerifyOptions{Owned: true},
+			want:    []string{"extra: java/Main.java", "extra: mipmap-mdpi/ic_other.png"},
+		},
+		{
+			name: "extra patterns",
+			project: map[string][]byte{
+				"mipmap-hdpi/ic_legacy.png": icon,
+				"mipmap-hdpi/ic_other.png":  icon,
+				// the patterns apply in the kinds of folders that are generated only
+				"drawable/ic_legacy.png": icon,
+			},
+			options: VerifyOptions{ExtraPatterns: []string{"*/ic_legacy.png"}},
+			want:    []string{"extra: mipmap-hdpi/ic_legacy.png"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectedDir, projectDir := t.TempDir(), t.TempDir()
+			writeFiles(t, expectedDir, expected)
+			writeFiles(t, projectDir, expected)
+			for name, data := range tt.project {
+				if data == nil {
+					os.Remove(filepath.Join(projectDir, filepath.FromSlash(name)))
+					delete(tt.project, name)
+				}
+			}
+			writeFiles(t, projectDir, tt.project)
+
+			issues, err := VerifyDir(expectedDir, projectDir, tt.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, issue := range issues {
+				rel, err := filepath.Rel(projectDir, issue.Path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, string(issue.Kind)+": "+filepath.ToSlash(rel))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("VerifyDir() = %v, want %v", issues, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyDirMissingProject(t *testing.T) {
+	expectedDir := t.TempDir()
+	writeFiles(t, expectedDir, map[string][]byte{"ios/Contents.json": []byte("{}")})
+
+	issues, err := VerifyDir(expectedDir, filepath.Join(t.TempDir(), "missing"), VerifyOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 || issues[0].Kind != VerifyIssueMissing {
+		t.Errorf("VerifyDir() = %v, want one missing file", issues)
+	}
+}
+
+func TestPixelDeltaE(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    color.NRGBA
+		wantMin float64
+		wantMax float64
+	}{
+		{name: "same", a: color.NRGBA{10, 20, 30, 255}, b: color.NRGBA{10, 20, 30, 255}, wantMax: 0},
+		{name: "transparent with other colors", a: color.NRGBA{255, 0, 0, 0}, b: color.NRGBA{0, 0, 255, 0}, wantMax: 0},
+		{name: "one step", a: color.NRGBA{200, 40, 40, 255}, b: color.NRGBA{201, 40, 40, 255}, wantMax: DefaultVerifyTolerance},
+		{name: "red and blue", a: color.NRGBA{255, 0, 0, 255}, b: color.NRGBA{0, 0, 255, 255}, wantMin: 100, wantMax: 1000},
+		{name: "only the alpha differs", a: color.NRGBA{255, 255, 255, 255}, b: color.NRGBA{255, 255, 255, 128}, wantMin: 10, wantMax: 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := []uint8{tt.a.R, tt.a.G, tt.a.B, tt.a.A}
+			b := []uint8{tt.b.R, tt.b.G, tt.b.B, tt.b.A}
+			got := pixelDeltaE(a, b)
+			if got < tt.wantMin || got > tt.wantMax+1e-9 {
+				t.Errorf("pixelDeltaE() = %v, want between %v and %v", got, tt.wantMin, tt.wantMax)
+			}
+			if reverse := pixelDeltaE(b, a); !nearlyEqual(reverse, got) {
+				t.Errorf("pixelDeltaE() is not symmetric, %v and %v", got, reverse)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"errors"
+	"slices"
 	"sync"
 
 	"github.com/Nidal-Bakir/assets-gen/assetsgen"
@@ -10,23 +11,178 @@ import (
 	"github.com/urfave/cli/v3"
 )
 
+// The generation options of the all command, shared with the verify command that regenerates the same files
+type allOptions struct {
+	bg bgIconOptions
+
+	maskColor                  *colorful.Color
+	darkBgIcon                 assetsgen.BackgroundIcon
+	trimWhiteSpace             bool
+	trim                       assetsgen.TrimOptions
+	opticalCentering           float64
+	autoFit                    assetsgen.AdaptiveIconShape
+	resize                     assetsgen.ResizeOptions
+	roundedCornerPercentRadius float64
+	alphaThreshold             float64
+	padding                    float64
+	folderName                 assetsgen.AndroidFolderName
+}
+
+func newAllOptions() allOptions {
+	return allOptions{
+		bg:         newBgIconOptions(),
+		resize:     assetsgen.DefaultResizeOptions(),
+		folderName: assetsgen.AndroidFolderMipmap,
+	}
+}
+
+func (o *allOptions) flags() []cli.Flag {
+	return slices.Concat(
+		[]cli.Flag{
+			cornerRadiusFlagFn(&o.roundedCornerPercentRadius),
+			androidFolderFlag(&o.folderName),
+			paddingFlagFn(&o.padding),
+			opticalCenterFlagFn(&o.opticalCentering),
+			autoFitFlagFn(&o.autoFit),
+			alphaThresholdFlagFn(&o.alphaThreshold),
+		},
+		bgIconFlags(&o.bg),
+		resizeFlags(&o.resize),
+		[]cli.Flag{
+			trimWhiteSpaceFlagFn(&o.trimWhiteSpace),
+		},
+		trimFlags(&o.trim),
+		[]cli.Flag{
+			maskColorFlagFn(&o.maskColor),
+			darkColorFlagFn(&o.darkBgIcon),
+		},
+	)
+}
+
+func (o allOptions) androidAppIconOptions(bgIcon assetsgen.BackgroundIcon, output assetsgen.OutputSink) assetsgen.AndroidAppIconOptions {
+	return assetsgen.AndroidAppIconOptions{
+		RoundedCornerPercentRadius: o.roundedCornerPercentRadius,
+		FolderName:                 o.folderName,
+		Padding:                    o.padding,
+		BgIcon:                     bgIcon,
+		AlphaThreshold:             o.alphaThreshold,
+		TrimWhiteSpace:             o.trimWhiteSpace,
+		Trim:                       o.trim,
+		OpticalCentering:           o.opticalCentering,
+		AutoFit:                    o.autoFit,
+		MaskColor:                  o.maskColor,
+		OutputFileName:             "ic_launcher",
+		Resize:                     o.resize,
+		Output:                     output,
+	}
+}
+
+func (o allOptions) targetOptions(bgIcon assetsgen.BackgroundIcon, output assetsgen.OutputSink) assetsgen.TargetOptions {
+	return assetsgen.TargetOptions{
+		AlphaThreshold:   o.alphaThreshold,
+		BgIcon:           bgIcon,
+		Padding:          o.padding,
+		TrimWhiteSpace:   o.trimWhiteSpace,
+		Trim:             o.trim,
+		OpticalCentering: o.opticalCentering,
+		MaskColor:        o.maskColor,
+		Resize:           o.resize,
+		Output:           output,
+	}
+}
+
+// Runs all the generators in parallel. [output] nil for the default out folder
+func (o allOptions) generate(src *assetsgen.SourceImage, output assetsgen.OutputSink) error {
+	bgIcon, err := getBgIcon(o.bg)
+	if err != nil {
+		return err
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(4)
+	errSlice := make([]error, 4)
+
+	go func() {
+		defer wg.Done()
+		errSlice[0] = assetsgen.GenerateAppIconForAndroidFromSource(
+			src,
+			o.androidAppIconOptions(bgIcon, output),
+		)
+	}()
+
+	go func() {
+		defer wg.Done()
+		errSlice[1] = assetsgen.GenerateAndroidGooglePlayLogoFromSource(
+			src,
+			assetsgen.AndroidGooglePlayLogoOptions{
+				Padding:          o.padding,
+				BgIcon:           bgIcon,
+				AlphaThreshold:   o.alphaThreshold,
+				TrimWhiteSpace:   o.trimWhiteSpace,
+				Trim:             o.trim,
+				OpticalCentering: o.opticalCentering,
+				MaskColor:        o.maskColor,
+				OutputFileName:   "play_store_logo_512x512",
+				Resize:           o.resize,
+				Output:           output,
+			},
+		)
+	}()
+
+	go func() {
+		defer wg.Done()
+		errSlice[2] = assetsgen.GenerateNotificationIconForAndroidFromSource(
+			src,
+			assetsgen.AndroidNotificationIconOptions{
+				FolderName:       o.folderName,
+				TrimWhiteSpace:   o.trimWhiteSpace,
+				Trim:             o.trim,
+				OpticalCentering: o.opticalCentering,
+				OutputFileName:   "ic_stat_notification_icon",
+				AlphaThreshold:   o.alphaThreshold,
+				Resize:           o.resize,
+				Output:           output,
+			},
+		)
+	}()
+
+	go func() {
+		defer wg.Done()
+		errSlice[3] = assetsgen.GenerateAppIconForIosFromSource(
+			src,
+			assetsgen.IosAppIconOptions{
+				BgIcon:           bgIcon,
+				Padding:          o.padding,
+				AlphaThreshold:   o.alphaThreshold,
+				TrimWhiteSpace:   o.trimWhiteSpace,
+				Trim:             o.trim,
+				OpticalCentering: o.opticalCentering,
+				MaskColor:        o.maskColor,
+				DarkBgIcon:       o.darkBgIcon,
+				Resize:           o.resize,
+				Output:           output,
+			},
+		)
+	}()
+
+	wg.Wait()
+
+	return errors.Join(errSlice...)
+}
+
 func GenerateAll() *cli.Command {
 	var imagePath string
 
-	var bgType string
-	var bgImagePath string
-	var linearGradientDegree int
-	var solidColor = colorful.Color{R: 1, G: 1, B: 1}
-	var gradientColors = []colorful.Color{{R: 1, G: 1, B: 1}, {R: 0, G: 0, B: 0}}
-	var gradientStops = []float64{0.0, 1.0}
-
-	var maskColor *colorful.Color
-	var trimWhiteSpace bool
+	options := newAllOptions()
+	var safeZoneReport bool
+	var preview bool
+	var previewFormat = assetsgen.PreviewFormatPng
 	var apply bool
-	var roundedCornerPercentRadius float64
-	var alphaThreshold float64
-	var padding float64
-	var folderName = assetsgen.AndroidFolderMipmap
+	var zipPath string
+	var watch bool
+	var targetSpecPath string
+	var useCache bool
+	var cacheDir string
 
 	imageArg := imageArg(&imagePath)
 
@@ -38,89 +194,95 @@ func GenerateAll() *cli.Command {
 			return assetsgen.ErrFileNotFound
 		}
 
-		bgIcon, err := getBgIcon(bgType, gradientColors, gradientStops, solidColor, linearGradientDegree, bgImagePath)
-		if err != nil {
-			return err
+		if zipPath != "" && (apply || preview) {
+			return ErrZipCanNotBeUsedWithApply
 		}
 
-		wg := sync.WaitGroup{}
-		wg.Add(4)
-		errSlice := make([]error, 4)
-
-		go func() {
-			defer wg.Done()
-			errSlice[0] = assetsgen.GenerateAppIconForAndroid(
-				imagePath,
-				assetsgen.AndroidAppIconOptions{
-					RoundedCornerPercentRadius: roundedCornerPercentRadius,
-					FolderName:                 folderName,
-					Padding:                    padding,
-					BgIcon:                     bgIcon,
-					AlphaThreshold:             alphaThreshold,
-					TrimWhiteSpace:             trimWhiteSpace,
-					MaskColor:                  maskColor,
-					OutputFileName:             "ic_launcher",
-				},
-			)
-		}()
-
-		go func() {
-			defer wg.Done()
-			errSlice[1] = assetsgen.GenerateAndroidGooglePlayLogo(
-				imagePath,
-				assetsgen.AndroidGooglePlayLogoOptions{
-					Padding:        padding,
-					BgIcon:         bgIcon,
-					AlphaThreshold: alphaThreshold,
-					TrimWhiteSpace: trimWhiteSpace,
-					MaskColor:      maskColor,
-					OutputFileName: "play_store_logo_512x512",
-				},
-			)
-		}()
-
-		go func() {
-			defer wg.Done()
-			errSlice[2] = assetsgen.GenerateNotificationIconForAndroid(
-				imagePath,
-				assetsgen.AndroidNotificationIconOptions{
-					FolderName:     folderName,
-					TrimWhiteSpace: trimWhiteSpace,
-					OutputFileName: "ic_stat_notification_icon",
-					AlphaThreshold: alphaThreshold,
-				},
-			)
-		}()
-
-		go func() {
-			defer wg.Done()
-			errSlice[3] = assetsgen.GenerateAppIconForIos(
-				imagePath,
-				assetsgen.IosAppIconOptions{
-					BgIcon:         bgIcon,
-					Padding:        padding,
-					AlphaThreshold: alphaThreshold,
-					TrimWhiteSpace: trimWhiteSpace,
-					MaskColor:      maskColor,
-				},
-			)
-		}()
-
-		wg.Wait()
-
-		err = errors.Join(errSlice...)
-		if err != nil {
-			return err
+		var cache *outputCache
+		if useCache {
+			var err error
+			cache, err = openOutputCache(cacheDir)
+			if err != nil {
+				return err
+			}
 		}
 
-		if apply {
-			err = applyAll()
+		return runWatching(ctx, watch, watchedFiles(imagePath, targetSpecPath, options.bg.bgImagePath), func(ctx context.Context) error {
+			// decoded once and shared between the generators, and not at all when the outputs come from the cache
+			loadSrc := sync.OnceValues(func() (*assetsgen.SourceImage, error) {
+				return assetsgen.LoadSourceImage(imagePath)
+			})
+
+			var spec assetsgen.TargetSpec
+			var err error
+			if targetSpecPath != "" {
+				spec, err = assetsgen.LoadTargetSpec(targetSpecPath)
+				if err != nil {
+					return err
+				}
+			}
+
+			if safeZoneReport {
+				src, err := loadSrc()
+				if err != nil {
+					return err
+				}
+				bgIcon, err := getBgIcon(options.bg)
+				if err != nil {
+					return err
+				}
+				printSafeZoneReport(assetsgen.AnalyzeAdaptiveAppIcon(src, options.androidAppIconOptions(bgIcon, nil)))
+			}
+
+			generate := func(output assetsgen.OutputSink) error {
+				src, err := loadSrc()
+				if err != nil {
+					return err
+				}
+
+				err = options.generate(src, output)
+				if err != nil || len(spec.Targets) == 0 {
+					return err
+				}
+
+				bgIcon, err := getBgIcon(options.bg)
+				if err != nil {
+					return err
+				}
+				return assetsgen.GenerateTargetsFromSource(src, spec, options.targetOptions(bgIcon, output))
+			}
+
+			err = withOutputSink(ctx, zipPath, func(output assetsgen.OutputSink) error {
+				if cache == nil {
+					return generate(output)
+				}
+
+				key, err := allCacheKey(imagePath, options, spec)
+				if err != nil {
+					return err
+				}
+				return cache.through(key, output, generate)
+			})
 			if err != nil {
 				return err
 			}
-		}
 
-		return nil
+			if preview {
+				err = generatePreview(previewFormat, apply)
+				if err != nil {
+					return err
+				}
+			}
+
+			if apply {
+				err = applyAll()
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
 	}
 
 	return &cli.Command{
@@ -130,21 +292,19 @@ func GenerateAll() *cli.Command {
 		Arguments: []cli.Argument{
 			imageArg,
 		},
-		Flags: []cli.Flag{
-			cornerRadiusFlagFn(&roundedCornerPercentRadius),
-			androidFolderFlag(&folderName),
-			paddingFlagFn(&padding),
-			alphaThresholdFlagFn(&alphaThreshold),
-			bgTypeFlagFn(&bgType),
-			solidColorFlagFn(&solidColor),
-			gradientColorsFlagFn(&gradientColors),
-			gradientStopsFlagFn(&gradientStops),
-			linearGradientDegreeFlagFn(&linearGradientDegree),
-			imageBgFlagFn(&bgImagePath),
-			trimWhiteSpaceFlagFn(&trimWhiteSpace),
-			maskColorFlagFn(&maskColor),
-			applyFlagFn(&apply),
-		},
+		Flags: slices.Concat(
+			options.flags(),
+			[]cli.Flag{
+				safeZoneReportFlagFn(&safeZoneReport),
+				previewFlagFn(&preview),
+				previewFormatFlagFn(&previewFormat),
+				applyFlagFn(&apply),
+				zipFlagFn(&zipPath),
+				watchFlagFn(&watch),
+				targetSpecFlagFn("targets", &targetSpecPath),
+			},
+			cacheFlags(&useCache, &cacheDir),
+		),
 	}
 }
 
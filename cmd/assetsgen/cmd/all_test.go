@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/Nidal-Bakir/assets-gen/assetsgen"
+)
+
+// The full all generation of a large logo, without touching the disk
+func BenchmarkGenerateAll(b *testing.B) {
+	const size = 4096
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	c, r := float64(size)/2, float64(size)*0.4
+	for y := range size {
+		for x := range size {
+			d := math.Hypot(float64(x)+0.5-c, float64(y)+0.5-c)
+			a := math.Max(0, math.Min(1, r-d+0.5))
+			img.SetNRGBA(x, y, color.NRGBA{R: 51, G: 102, B: 204, A: uint8(a*255 + 0.5)})
+		}
+	}
+
+	options := newAllOptions()
+	options.bg.bgType = "solid-color"
+	options.alphaThreshold = 0.5
+
+	b.ReportAllocs()
+	for b.Loop() {
+		// a new source every time, it caches the trimmed and padded logos
+		src, err := assetsgen.NewSourceImage(img, "logo.png")
+		if err != nil {
+			b.Fatal(err)
+		}
+		err = options.generate(src, assetsgen.NewMemorySink())
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"slices"
 
 	"github.com/Nidal-Bakir/assets-gen/assetsgen"
 	"github.com/lucasb-eyer/go-colorful"
@@ -12,16 +13,19 @@ func AndroidAppIcon() *cli.Command {
 	var imagePath string
 	var outputName string
 
-	var bgType string
-	var bgImagePath string
-	var linearGradientDegree int
-	var solidColor = colorful.Color{R: 1, G: 1, B: 1}
-	var gradientColors = []colorful.Color{{R: 1, G: 1, B: 1}, {R: 0, G: 0, B: 0}}
-	var gradientStops = []float64{0.0, 1.0}
+	var bg = newBgIconOptions()
 
 	var maskColor *colorful.Color
 	var trimWhiteSpace bool
+	var trim assetsgen.TrimOptions
+	var opticalCentering float64
+	var autoFit assetsgen.AdaptiveIconShape
+	var safeZoneReport bool
 	var apply bool
+	var zipPath string
+	var watch bool
+	var sanitizeName bool
+	resize := assetsgen.DefaultResizeOptions()
 	var roundedCornerPercentRadius float64
 	var alphaThreshold float64
 	var padding float64
@@ -37,36 +41,63 @@ func AndroidAppIcon() *cli.Command {
 			return assetsgen.ErrFileNotFound
 		}
 
-		bgIcon, err := getBgIcon(bgType, gradientColors, gradientStops, solidColor, linearGradientDegree, bgImagePath)
+		if zipPath != "" && apply {
+			return ErrZipCanNotBeUsedWithApply
+		}
+
+		outputName, err := checkAndroidResourceName(outputName, sanitizeName)
 		if err != nil {
 			return err
 		}
 
-		err = assetsgen.GenerateAppIconForAndroid(
-			imagePath,
-			assetsgen.AndroidAppIconOptions{
+		return runWatching(ctx, watch, watchedFiles(imagePath, bg.bgImagePath), func(ctx context.Context) error {
+			bgIcon, err := getBgIcon(bg)
+			if err != nil {
+				return err
+			}
+
+			src, err := assetsgen.LoadSourceImage(imagePath)
+			if err != nil {
+				return err
+			}
+
+			options := assetsgen.AndroidAppIconOptions{
 				RoundedCornerPercentRadius: roundedCornerPercentRadius,
 				FolderName:                 folderName,
 				Padding:                    padding,
 				BgIcon:                     bgIcon,
 				AlphaThreshold:             alphaThreshold,
 				TrimWhiteSpace:             trimWhiteSpace,
+				Trim:                       trim,
+				OpticalCentering:           opticalCentering,
+				AutoFit:                    autoFit,
 				MaskColor:                  maskColor,
 				OutputFileName:             outputName,
-			},
-		)
-		if err != nil {
-			return err
-		}
+				SanitizeName:               sanitizeName,
+				Resize:                     resize,
+			}
+
+			if safeZoneReport {
+				printSafeZoneReport(assetsgen.AnalyzeAdaptiveAppIcon(src, options))
+			}
 
-		if apply {
-			err = applyAndroidAppIcon(outputName)
+			err = withOutputSink(ctx, zipPath, func(output assetsgen.OutputSink) error {
+				options.Output = output
+				return assetsgen.GenerateAppIconForAndroidFromSource(src, options)
+			})
 			if err != nil {
 				return err
 			}
-		}
 
-		return nil
+			if apply {
+				err = applyAndroidAppIcon(outputName)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
 	}
 
 	usageText := `android-app-icon [command [command options]] <image path>
@@ -75,7 +106,10 @@ examples:
 	aai "./ic_launcher.png"
 	aai -bg linear-gradient --degree 90 --colors "#FF0000, #00FF00, #0000FF" --stops "0.0, 0.5, 1.0" "./ic_launcher.png"
 	aai --color "#0000FF" "./ic_launcher.png"
-	aai --apply -o "app_icon" -p 0.1 --trim "./ic_launcher.png"`
+	aai --apply -o "app_icon" -p 0.1 --trim "./ic_launcher.png"
+	aai --auto-fit safe-zone --safe-zone-report "./ic_launcher.png"
+	aai --zip "android_icons.zip" "./ic_launcher.png"
+	aai --watch --apply "./ic_launcher.png"`
 
 	return &cli.Command{
 		Name:      "android-app-icon",
@@ -86,22 +120,31 @@ examples:
 		Arguments: []cli.Argument{
 			imageArg,
 		},
-		Flags: []cli.Flag{
-			cornerRadiusFlagFn(&roundedCornerPercentRadius),
-			androidFolderFlag(&folderName),
-			paddingFlagFn(&padding),
-			alphaThresholdFlagFn(&alphaThreshold),
-			outputNameFlagFn(&outputName, "ic_launcher"),
-			bgTypeFlagFn(&bgType),
-			solidColorFlagFn(&solidColor),
-			gradientColorsFlagFn(&gradientColors),
-			gradientStopsFlagFn(&gradientStops),
-			linearGradientDegreeFlagFn(&linearGradientDegree),
-			imageBgFlagFn(&bgImagePath),
-			trimWhiteSpaceFlagFn(&trimWhiteSpace),
-			maskColorFlagFn(&maskColor),
-			applyFlagFn(&apply),
-		},
+		Flags: slices.Concat(
+			[]cli.Flag{
+				cornerRadiusFlagFn(&roundedCornerPercentRadius),
+				androidFolderFlag(&folderName),
+				paddingFlagFn(&padding),
+				opticalCenterFlagFn(&opticalCentering),
+				autoFitFlagFn(&autoFit),
+				safeZoneReportFlagFn(&safeZoneReport),
+				alphaThresholdFlagFn(&alphaThreshold),
+				outputNameFlagFn(&outputName, "ic_launcher"),
+			},
+			bgIconFlags(&bg),
+			resizeFlags(&resize),
+			[]cli.Flag{
+				trimWhiteSpaceFlagFn(&trimWhiteSpace),
+			},
+			trimFlags(&trim),
+			[]cli.Flag{
+				maskColorFlagFn(&maskColor),
+				applyFlagFn(&apply),
+				sanitizeNameFlagFn(&sanitizeName),
+				zipFlagFn(&zipPath),
+				watchFlagFn(&watch),
+			},
+		),
 	}
 }
 
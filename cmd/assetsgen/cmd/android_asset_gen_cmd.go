@@ -2,6 +2,13 @@ package cmd
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/Nidal-Bakir/assets-gen/assetsgen"
 	"github.com/urfave/cli/v3"
@@ -9,61 +16,125 @@ import (
 
 // android-asset-gen (aag)
 func AndroidAssetGen() *cli.Command {
-	var imagePath string
+	var inputs []string
+	var jobs int
 	var trimWhiteSpace bool
+	var trim assetsgen.TrimOptions
 	var apply bool
+	var zipPath string
+	var watch bool
+	var sanitizeName bool
+	resize := assetsgen.DefaultResizeOptions()
 
 	folderName := assetsgen.AndroidFolderDrawable
 
 	action := func(ctx context.Context, c *cli.Command) error {
-		if b := isPathExist(imagePath); !b {
-			if len(imagePath) == 0 {
-				return ErrPleaseSpecifyImagePath
-			}
-			return assetsgen.ErrFileNotFound
+		if len(inputs) == 0 {
+			return ErrPleaseSpecifyImagePath
 		}
 
-		err := assetsgen.GenerateImageAssetsForAndroid(
-			imagePath, assetsgen.AndroidImageAssetsOptions{
-				FolderName:     folderName,
-				TrimWhiteSpace: trimWhiteSpace,
-			},
-		)
+		if zipPath != "" && apply {
+			return ErrZipCanNotBeUsedWithApply
+		}
+
+		imagePaths, batch, err := expandImageInputs(inputs)
 		if err != nil {
 			return err
 		}
-
-		if apply {
-			err = applyAndroidAssetImage()
+		outputName := ""
+		if !batch {
+			outputName, err = checkAndroidResourceName(imageBaseName(imagePaths[0]), sanitizeName)
 			if err != nil {
 				return err
 			}
 		}
 
-		return nil
+		return runWatching(ctx, watch, watchedFiles(slices.Concat(imagePaths, inputsDirs(inputs))...), func(ctx context.Context) error {
+			options := assetsgen.AndroidImageAssetsOptions{
+				FolderName:     folderName,
+				TrimWhiteSpace: trimWhiteSpace,
+				Trim:           trim,
+				Resize:         resize,
+				OutputFileName: outputName,
+			}
+
+			var batchErr error
+			err := withOutputSink(ctx, zipPath, func(output assetsgen.OutputSink) error {
+				options.Output = output
+				if !batch {
+					return assetsgen.GenerateImageAssetsForAndroid(imagePaths[0], options)
+				}
+
+				// picks up the images added to the folders since the previous run in watch mode
+				imagePaths, _, err := expandImageInputs(inputs)
+				if err != nil {
+					return err
+				}
+
+				// the failed images don't stop the others from being applied
+				var generated int
+				generated, batchErr = generateAndroidAssetsBatch(imagePaths, jobs, sanitizeName, options)
+				if generated == 0 {
+					return batchErr
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			if apply {
+				err = applyAndroidAssetImage()
+				if err != nil {
+					return err
+				}
+			}
+
+			return batchErr
+		})
 	}
 
-	usageText := `android-asset-gen [command [command options]] <image path>
+	usageText := `android-asset-gen [command [command options]] <image path, folder or glob>...
 
 examples:
 	aag "./clear_sky.png"
 	aag --folder-name drawable --trim "./clear_sky.png"
-	aag --apply "./clear_sky.png"`
+	aag --apply "./clear_sky.png"
+	aag --zip "clear_sky.zip" "./clear_sky.png"
+	aag --watch "./clear_sky.png"
+	aag --jobs 4 "./illustrations"
+	aag "./illustrations/*.png" "./icons/ic_*.jpg"`
 
 	return &cli.Command{
 		Name:      "android-asset-gen",
 		Aliases:   []string{"aag"},
 		UsageText: usageText,
-		Usage:     "Generate Android asset image for all DPIs",
+		Usage:     "Generate Android asset image for all DPIs, of one image or a batch of images",
 		Action:    action,
 		Arguments: []cli.Argument{
-			imageArg(&imagePath),
-		},
-		Flags: []cli.Flag{
-			androidFolderFlag(&folderName),
-			trimWhiteSpaceFlagFn(&trimWhiteSpace),
-			applyFlagFn(&apply),
+			&cli.StringArgs{
+				Name:        "image",
+				UsageText:   "<image path, folder or glob>...",
+				Min:         0,
+				Max:         -1,
+				Destination: &inputs,
+			},
 		},
+		Flags: slices.Concat(
+			[]cli.Flag{
+				androidFolderFlag(&folderName),
+				trimWhiteSpaceFlagFn(&trimWhiteSpace),
+				jobsFlagFn(&jobs),
+			},
+			trimFlags(&trim),
+			resizeFlags(&resize),
+			[]cli.Flag{
+				sanitizeNameFlagFn(&sanitizeName),
+				applyFlagFn(&apply),
+				zipFlagFn(&zipPath),
+				watchFlagFn(&watch),
+			},
+		),
 	}
 }
 
@@ -78,3 +149,152 @@ func applyAndroidAssetImage() error {
 	}
 	return nil
 }
+
+func jobsFlagFn(jobs *int) *cli.IntFlag {
+	return &cli.IntFlag{
+		Name:        "jobs",
+		Aliases:     []string{"j"},
+		Value:       runtime.NumCPU(),
+		Usage:       "How many images of a batch are generated at the same time",
+		Destination: jobs,
+		Validator: func(i int) error {
+			if i < 1 {
+				return ErrInvalidValueRange
+			}
+			return nil
+		},
+	}
+}
+
+// The images of [inputs]: the files as they are, the png and jpg files of the folders and the matches of the glob patterns.
+// [batch] is false for a single image file, that keeps its name as it is
+func expandImageInputs(inputs []string) (imagePaths []string, batch bool, err error) {
+	batch = len(inputs) > 1
+	seen := map[string]bool{}
+	add := func(p string) {
+		if !seen[p] {
+			seen[p] = true
+			imagePaths = append(imagePaths, p)
+		}
+	}
+
+	for _, input := range inputs {
+		info, statErr := os.Stat(input)
+		switch {
+		case statErr == nil && info.IsDir():
+			batch = true
+			entries, err := os.ReadDir(input)
+			if err != nil {
+				return nil, false, err
+			}
+			for _, e := range entries {
+				p := filepath.Join(input, e.Name())
+				if !e.IsDir() && assetsgen.IsFileExistsAndImage(p) == nil {
+					add(p)
+				}
+			}
+
+		case statErr == nil:
+			add(input)
+
+		case isGlobPattern(input):
+			batch = true
+			matches, err := filepath.Glob(input)
+			if err != nil {
+				return nil, false, fmt.Errorf("%w: %s", err, input)
+			}
+			for _, p := range matches {
+				if assetsgen.IsFileExistsAndImage(p) == nil {
+					add(p)
+				}
+			}
+
+		default:
+			return nil, false, fmt.Errorf("%w: %s", assetsgen.ErrFileNotFound, input)
+		}
+	}
+
+	if len(imagePaths) == 0 {
+		return nil, false, ErrNoImagesFound
+	}
+	return imagePaths, batch, nil
+}
+
+func isGlobPattern(p string) bool {
+	return strings.ContainsAny(p, "*?[")
+}
+
+// The folders to watch for the added and the removed images, the folders of [inputs] and the folders of the glob patterns
+func inputsDirs(inputs []string) []string {
+	var dirs []string
+	for _, input := range inputs {
+		if isGlobPattern(input) {
+			dirs = append(dirs, filepath.Dir(input))
+		} else if info, err := os.Stat(input); err == nil && info.IsDir() {
+			dirs = append(dirs, input)
+		}
+	}
+	return dirs
+}
+
+// Generates the images [jobs] at a time, each named after its file, sanitized into a valid Android resource name with [sanitizeName].
+// A failed image, or one with an invalid name, is reported and doesn't stop the others. Returns how many images were generated
+func generateAndroidAssetsBatch(imagePaths []string, jobs int, sanitizeName bool, options assetsgen.AndroidImageAssetsOptions) (int, error) {
+	var mu sync.Mutex
+	generated, failed := 0, 0
+	report := func(imagePath, name string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			failed++
+			fmt.Printf("failed: %s: %v\n", imagePath, err)
+			return
+		}
+		generated++
+		fmt.Println("generated:", imagePath, "->", name)
+	}
+
+	wg := sync.WaitGroup{}
+	sem := make(chan struct{}, jobs)
+	nameOwners := map[string]string{}
+
+	for _, imagePath := range imagePaths {
+		// without --sanitize-name an invalid name fails only its own file
+		name, err := checkAndroidResourceName(imageBaseName(imagePath), sanitizeName)
+		if err != nil {
+			report(imagePath, name, err)
+			continue
+		}
+
+		// two files would overwrite each other, e.g: "My Icon.png" and "my_icon.png"
+		if owner, found := nameOwners[name]; found {
+			report(imagePath, name, fmt.Errorf("%w %q with %s", ErrDuplicateResourceName, name, owner))
+			continue
+		}
+		nameOwners[name] = imagePath
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			options := options
+			options.OutputFileName = name
+			report(imagePath, name, assetsgen.GenerateImageAssetsForAndroid(imagePath, options))
+		}()
+	}
+
+	wg.Wait()
+
+	fmt.Println(generated, "generated,", failed, "failed")
+	if failed != 0 {
+		return generated, fmt.Errorf("%w: %d of %d", ErrSomeImagesFailed, failed, len(imagePaths))
+	}
+	return generated, nil
+}
+
+// The file name without its extension, e.g: "icons/ic_home.png" is "ic_home"
+func imageBaseName(imagePath string) string {
+	return strings.TrimSuffix(filepath.Base(imagePath), filepath.Ext(imagePath))
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"errors"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Nidal-Bakir/assets-gen/assetsgen"
+)
+
+func TestExpandImageInputs(t *testing.T) {
+	dir := t.TempDir()
+	logo := string(testLogoPng(t))
+	writeTestFile(t, filepath.Join(dir, "a.png"), logo)
+	writeTestFile(t, filepath.Join(dir, "b.jpg"), logo)
+	writeTestFile(t, filepath.Join(dir, "notes.txt"), "not an image")
+	err := os.Mkdir(filepath.Join(dir, "sub"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(dir, "sub", "c.png"), logo)
+	emptyDir := filepath.Join(dir, "empty")
+	err = os.Mkdir(emptyDir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, b := filepath.Join(dir, "a.png"), filepath.Join(dir, "b.jpg")
+	tests := []struct {
+		name      string
+		inputs    []string
+		want      []string
+		wantBatch bool
+		wantErr   error
+	}{
+		{
+			name:   "single file",
+			inputs: []string{a},
+			want:   []string{a},
+		},
+		{
+			// not the sub folders or the other files
+			name:      "folder",
+			inputs:    []string{dir},
+			want:      []string{a, b},
+			wantBatch: true,
+		},
+		{
+			name:      "glob",
+			inputs:    []string{filepath.Join(dir, "*.png")},
+			want:      []string{a},
+			wantBatch: true,
+		},
+		{
+			name:      "duplicates",
+			inputs:    []string{a, dir, filepath.Join(dir, "*")},
+			want:      []string{a, b},
+			wantBatch: true,
+		},
+		{
+			name:    "missing",
+			inputs:  []string{filepath.Join(dir, "missing.png")},
+			wantErr: assetsgen.ErrFileNotFound,
+		},
+		{
+			name:    "no images",
+			inputs:  []string{emptyDir, filepath.Join(dir, "*.webp")},
+			wantErr: ErrNoImagesFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, batch, err := expandImageInputs(tt.inputs)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("paths = %v, want %v", got, tt.want)
+			}
+			if batch != tt.wantBatch {
+				t.Errorf("batch = %v, want %v", batch, tt.wantBatch)
+			}
+		})
+	}
+}
+
+func TestGenerateAndroidAssetsBatch(t *testing.T) {
+	dir := t.TempDir()
+	logo := string(testLogoPng(t))
+	var imagePaths []string
+	for name, content := range map[string]string{
+		"My Icon.png": logo,
+		"my_icon.png": logo,
+		"ok.png":      logo,
+		"broken.png":  "not a png",
+	} {
+		p := filepath.Join(dir, name)
+		writeTestFile(t, p, content)
+		imagePaths = append(imagePaths, p)
+	}
+	// the first one owns the name
+	slices.Sort(imagePaths)
+
+	tests := []struct {
+		name          string
+		sanitizeName  bool
+		wantGenerated int
+		wantNames     []string
+	}{
+		{
+			// "My Icon" is sanitized into the name of "my_icon.png", the second one fails
+			name:          "sanitize name",
+			sanitizeName:  true,
+			wantGenerated: 2,
+			wantNames:     []string{"my_icon", "ok"},
+		},
+		{
+			// "My Icon" is not a valid resource name
+			name:          "invalid name",
+			wantGenerated: 2,
+			wantNames:     []string{"my_icon", "ok"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := assetsgen.NewMemorySink()
+			generated, err := generateAndroidAssetsBatch(imagePaths, 2, tt.sanitizeName, assetsgen.AndroidImageAssetsOptions{
+				FolderName: assetsgen.AndroidFolderDrawable,
+				Resize:     assetsgen.DefaultResizeOptions(),
+				Output:     output,
+			})
+			if !errors.Is(err, ErrSomeImagesFailed) || !strings.Contains(err.Error(), "2 of 4") {
+				t.Errorf("err = %v, want %v: 2 of 4", err, ErrSomeImagesFailed)
+			}
+			if generated != tt.wantGenerated {
+				t.Errorf("generated = %d, want %d", generated, tt.wantGenerated)
+			}
+
+			names := map[string]bool{}
+			for p := range output.Files() {
+				names[strings.TrimSuffix(path.Base(p), path.Ext(p))] = true
+			}
+			if got := slices.Sorted(maps.Keys(names)); !slices.Equal(got, tt.wantNames) {
+				t.Errorf("the written names are %v, want %v", got, tt.wantNames)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"path/filepath"
+	"slices"
 
 	"github.com/Nidal-Bakir/assets-gen/assetsgen"
 	"github.com/lucasb-eyer/go-colorful"
@@ -13,16 +14,16 @@ func AndroidGooglePlayLogo() *cli.Command {
 	var imagePath string
 	var outputName string
 
-	var bgType string
-	var bgImagePath string
-	var linearGradientDegree int
-	var solidColor = colorful.Color{R: 1, G: 1, B: 1}
-	var gradientColors = []colorful.Color{{R: 1, G: 1, B: 1}, {R: 0, G: 0, B: 0}}
-	var gradientStops = []float64{0.0, 1.0}
+	var bg = newBgIconOptions()
 
 	var maskColor *colorful.Color
 	var trimWhiteSpace bool
+	var trim assetsgen.TrimOptions
+	var opticalCentering float64
 	var apply bool
+	var zipPath string
+	var watch bool
+	resize := assetsgen.DefaultResizeOptions()
 
 	var alphaThreshold float64
 	var padding float64
@@ -37,34 +38,46 @@ func AndroidGooglePlayLogo() *cli.Command {
 			return assetsgen.ErrFileNotFound
 		}
 
-		bgIcon, err := getBgIcon(bgType, gradientColors, gradientStops, solidColor, linearGradientDegree, bgImagePath)
-		if err != nil {
-			return err
+		if zipPath != "" && apply {
+			return ErrZipCanNotBeUsedWithApply
 		}
 
-		err = assetsgen.GenerateAndroidGooglePlayLogo(
-			imagePath,
-			assetsgen.AndroidGooglePlayLogoOptions{
-				Padding:        padding,
-				BgIcon:         bgIcon,
-				AlphaThreshold: alphaThreshold,
-				TrimWhiteSpace: trimWhiteSpace,
-				MaskColor:      maskColor,
-				OutputFileName: outputName,
-			},
-		)
-		if err != nil {
-			return err
-		}
+		return runWatching(ctx, watch, watchedFiles(imagePath, bg.bgImagePath), func(ctx context.Context) error {
+			bgIcon, err := getBgIcon(bg)
+			if err != nil {
+				return err
+			}
 
-		if apply {
-			err = applyAndroidPlayStoreLogo()
+			err = withOutputSink(ctx, zipPath, func(output assetsgen.OutputSink) error {
+				return assetsgen.GenerateAndroidGooglePlayLogo(
+					imagePath,
+					assetsgen.AndroidGooglePlayLogoOptions{
+						Padding:          padding,
+						BgIcon:           bgIcon,
+						AlphaThreshold:   alphaThreshold,
+						TrimWhiteSpace:   trimWhiteSpace,
+						Trim:             trim,
+						OpticalCentering: opticalCentering,
+						MaskColor:        maskColor,
+						OutputFileName:   outputName,
+						Resize:           resize,
+						Output:           output,
+					},
+				)
+			})
 			if err != nil {
 				return err
 			}
-		}
 
-		return nil
+			if apply {
+				err = applyAndroidPlayStoreLogo()
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
 	}
 
 	usageText := `android-app-icon [command [command options]] <image path>
@@ -73,7 +86,9 @@ examples:
 	apsl "./logo.png"
 	apsl -bg linear-gradient --degree 90 --colors "#FF0000, #00FF00, #0000FF" --stops "0.0, 0.5, 1.0" "./logo.png"
 	apsl --color "#0000FF" "./logo.png"
-	apsl --apply -o "play_store" -p 0.1 --trim "./logo.png"`
+	apsl --apply -o "play_store" -p 0.1 --trim "./logo.png"
+	agpl --zip "play_store.zip" "./logo.png"
+	agpl --watch "./logo.png"`
 
 	return &cli.Command{
 		Name:      "android-google-play-logo",
@@ -84,20 +99,26 @@ examples:
 		Arguments: []cli.Argument{
 			imageArg,
 		},
-		Flags: []cli.Flag{
-			paddingFlagFn(&padding),
-			alphaThresholdFlagFn(&alphaThreshold),
-			outputNameFlagFn(&outputName, "play_store_logo_512x512"),
-			bgTypeFlagFn(&bgType),
-			solidColorFlagFn(&solidColor),
-			gradientColorsFlagFn(&gradientColors),
-			gradientStopsFlagFn(&gradientStops),
-			linearGradientDegreeFlagFn(&linearGradientDegree),
-			imageBgFlagFn(&bgImagePath),
-			trimWhiteSpaceFlagFn(&trimWhiteSpace),
-			maskColorFlagFn(&maskColor),
-			applyFlagFn(&apply),
-		},
+		Flags: slices.Concat(
+			[]cli.Flag{
+				paddingFlagFn(&padding),
+				opticalCenterFlagFn(&opticalCentering),
+				alphaThresholdFlagFn(&alphaThreshold),
+				outputNameFlagFn(&outputName, "play_store_logo_512x512"),
+			},
+			bgIconFlags(&bg),
+			resizeFlags(&resize),
+			[]cli.Flag{
+				trimWhiteSpaceFlagFn(&trimWhiteSpace),
+			},
+			trimFlags(&trim),
+			[]cli.Flag{
+				maskColorFlagFn(&maskColor),
+				applyFlagFn(&apply),
+				zipFlagFn(&zipPath),
+				watchFlagFn(&watch),
+			},
+		),
 	}
 }
 
@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"slices"
 
 	"github.com/Nidal-Bakir/assets-gen/assetsgen"
 	"github.com/urfave/cli/v3"
@@ -14,8 +15,14 @@ func AndroidNotificationIcon() *cli.Command {
 	var outputName string
 	folderName := assetsgen.AndroidFolderMipmap
 	var trimWhiteSpace bool
+	var trim assetsgen.TrimOptions
+	var opticalCentering float64
 	var alphaThreshold float64
 	var apply bool
+	var zipPath string
+	var watch bool
+	var sanitizeName bool
+	resize := assetsgen.DefaultResizeOptions()
 
 	action := func(ctx context.Context, c *cli.Command) error {
 		if b := isPathExist(imagePath); !b {
@@ -25,34 +32,54 @@ func AndroidNotificationIcon() *cli.Command {
 			return assetsgen.ErrFileNotFound
 		}
 
-		err := assetsgen.GenerateNotificationIconForAndroid(
-			imagePath,
-			assetsgen.AndroidNotificationIconOptions{
-				FolderName:     folderName,
-				TrimWhiteSpace: trimWhiteSpace,
-				OutputFileName: outputName,
-				AlphaThreshold: alphaThreshold,
-			},
-		)
+		if zipPath != "" && apply {
+			return ErrZipCanNotBeUsedWithApply
+		}
+
+		outputName, err := checkAndroidResourceName(outputName, sanitizeName)
 		if err != nil {
 			return err
 		}
 
-		if apply {
-			err = applyAndroidNotificationIcon(string(folderName), outputName)
+		return runWatching(ctx, watch, watchedFiles(imagePath), func(ctx context.Context) error {
+			err := withOutputSink(ctx, zipPath, func(output assetsgen.OutputSink) error {
+				return assetsgen.GenerateNotificationIconForAndroid(
+					imagePath,
+					assetsgen.AndroidNotificationIconOptions{
+						FolderName:       folderName,
+						TrimWhiteSpace:   trimWhiteSpace,
+						Trim:             trim,
+						OpticalCentering: opticalCentering,
+						OutputFileName:   outputName,
+						SanitizeName:     sanitizeName,
+						AlphaThreshold:   alphaThreshold,
+						Resize:           resize,
+						Output:           output,
+					},
+				)
+			})
 			if err != nil {
 				return err
 			}
-		}
 
-		return nil
+			if apply {
+				err = applyAndroidNotificationIcon(string(folderName), outputName)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
 	}
 
 	usageText := `android-notification-icon [command [command options]] <image path>
 
 examples:
 	aai "./icon.png"
-	aai --apply -o "notification_icon" --trim "./icon.png"`
+	aai --apply -o "notification_icon" --trim "./icon.png"
+	ani --zip "notification_icon.zip" "./icon.png"
+	ani --watch --apply "./icon.png"`
 
 	return &cli.Command{
 		Name:      "android-notification-icon",
@@ -63,13 +90,25 @@ examples:
 		Arguments: []cli.Argument{
 			imageArg(&imagePath),
 		},
-		Flags: []cli.Flag{
-			androidFolderFlag(&folderName),
-			outputNameFlagFn(&outputName, "ic_stat_notification_icon"),
-			trimWhiteSpaceFlagFn(&trimWhiteSpace),
-			alphaThresholdFlagFn(&alphaThreshold),
-			applyFlagFn(&apply),
-		},
+		Flags: slices.Concat(
+			[]cli.Flag{
+				androidFolderFlag(&folderName),
+				outputNameFlagFn(&outputName, "ic_stat_notification_icon"),
+				trimWhiteSpaceFlagFn(&trimWhiteSpace),
+			},
+			trimFlags(&trim),
+			[]cli.Flag{
+				alphaThresholdFlagFn(&alphaThreshold),
+				opticalCenterFlagFn(&opticalCentering),
+			},
+			resizeFlags(&resize),
+			[]cli.Flag{
+				applyFlagFn(&apply),
+				sanitizeNameFlagFn(&sanitizeName),
+				zipFlagFn(&zipPath),
+				watchFlagFn(&watch),
+			},
+		),
 	}
 }
 
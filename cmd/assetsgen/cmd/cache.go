@@ -0,0 +1,311 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Nidal-Bakir/assets-gen/assetsgen"
+	"github.com/urfave/cli/v3"
+)
+
+// Bump to drop the entries written by the older versions when the layout or the key change
+const cacheVersion = "1"
+
+// The entries that were not used for this long are deleted
+const cacheMaxAge = 30 * 24 * time.Hour
+
+func cacheFlags(enabled *bool, dir *string) []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:        "cache",
+			Value:       false,
+			Usage:       "Copy the outputs from the cache when the image, the options and the targets spec didn't change since a previous run, instead of generating them again",
+			Destination: enabled,
+		},
+		&cli.StringFlag{
+			Name:        "cache-dir",
+			Usage:       "The folder of the cache. Defaults to assetsgen in the user cache folder, e.g: ~/.cache/assetsgen",
+			Destination: dir,
+		},
+	}
+}
+
+// The outputs of the previous runs, a folder per key with the files as they were written to the output
+type outputCache struct {
+	dir string
+}
+
+// Empty [dir] falls back to assetsgen in the user cache folder
+func openOutputCache(dir string) (*outputCache, error) {
+	if dir == "" {
+		userCacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(userCacheDir, "assetsgen")
+	}
+
+	err := os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+	return &outputCache{dir: dir}, nil
+}
+
+// Writes the cached files of [key] to [output], or runs [generate] and caches what it writes
+func (c *outputCache) through(key string, output assetsgen.OutputSink, generate func(output assetsgen.OutputSink) error) error {
+	return orDefaultOutput(output, func(output assetsgen.OutputSink) error {
+		restored, err := c.restore(key, output)
+		if err != nil || restored {
+			return err
+		}
+		return c.store(key, output, generate)
+	})
+}
+
+// False if there is no entry for [key], or the entry is broken (e.g: edited by hand) then it's deleted so it's generated again
+func (c *outputCache) restore(key string, output assetsgen.OutputSink) (bool, error) {
+	entry := filepath.Join(c.dir, key)
+	if !isPathExist(entry) {
+		return false, nil
+	}
+
+	// read in full before writing anything, a broken entry must not leave some of its files in the output
+	files := assetsgen.NewMemorySink()
+	err := fs.WalkDir(os.DirFS(entry), ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		return copyToOutput(files, name, filepath.Join(entry, filepath.FromSlash(name)))
+	})
+	if err != nil {
+		fmt.Println("the cache entry", entry, "is broken, generating again:", err)
+		os.RemoveAll(entry)
+		return false, nil
+	}
+
+	restored := files.Files()
+	for _, name := range slices.Sorted(maps.Keys(restored)) {
+		err = writeToOutput(output, name, restored[name])
+		if err != nil {
+			return false, err
+		}
+	}
+
+	// keeps the used entries from being pruned
+	now := time.Now()
+	os.Chtimes(entry, now, now)
+
+	fmt.Println("unchanged, copied", len(restored), "files from the cache")
+	return true, nil
+}
+
+// Runs [generate] with [output] teed into a new entry of [key], the entry is kept only if [generate] succeeds
+func (c *outputCache) store(key string, output assetsgen.OutputSink, generate func(output assetsgen.OutputSink) error) error {
+	tmp, err := os.MkdirTemp(c.dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+
+	entrySink, err := assetsgen.NewDirSink(tmp)
+	if err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+
+	err = generate(teeSink{output, entrySink})
+	entrySink.Close()
+	if err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+
+	// fails if another run stored the same key in the meantime, that one is as good as this one
+	err = os.Rename(tmp, filepath.Join(c.dir, key))
+	if err != nil {
+		os.RemoveAll(tmp)
+	}
+
+	c.prune()
+	return nil
+}
+
+// Best effort, the cache works without it
+func (c *outputCache) prune() {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		info, err := e.Info()
+		if err == nil && time.Since(info.ModTime()) > cacheMaxAge {
+			os.RemoveAll(filepath.Join(c.dir, e.Name()))
+		}
+	}
+}
+
+func copyToOutput(output assetsgen.OutputSink, name, filePath string) error {
+	src, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := output.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	if err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+func writeToOutput(output assetsgen.OutputSink, name string, data []byte) error {
+	dst, err := output.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = dst.Write(data)
+	if err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+// Writes every file to both sinks
+type teeSink [2]assetsgen.OutputSink
+
+func (t teeSink) Create(name string) (io.WriteCloser, error) {
+	first, err := t[0].Create(name)
+	if err != nil {
+		return nil, err
+	}
+	second, err := t[1].Create(name)
+	if err != nil {
+		first.Close()
+		return nil, err
+	}
+	return teeFile{first, second}, nil
+}
+
+type teeFile [2]io.WriteCloser
+
+func (t teeFile) Write(p []byte) (int, error) {
+	n, err := t[0].Write(p)
+	if err != nil {
+		return n, err
+	}
+	return t[1].Write(p)
+}
+
+func (t teeFile) Close() error {
+	err := t[0].Close()
+	if err2 := t[1].Close(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+// The key of the outputs of the all command. Anything that changes the outputs is part of it: the content of the
+// source and the background images, the options, the targets spec and the build of assetsgen itself
+func allCacheKey(imagePath string, options allOptions, spec assetsgen.TargetSpec) (string, error) {
+	h := sha256.New()
+	fmt.Fprintln(h, "assetsgen cache", cacheVersion, buildStamp())
+
+	// the extension picks the encoder of the outputs
+	fmt.Fprintln(h, strings.ToLower(filepath.Ext(imagePath)))
+	err := hashFile(h, imagePath)
+	if err != nil {
+		return "", err
+	}
+	if options.bg.bgType == "image" {
+		err = hashFile(h, options.bg.bgImagePath)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	writeCanonical(h, reflect.ValueOf(options))
+
+	err = json.NewEncoder(h).Encode(spec)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Changes with every build of the executable, so an upgrade doesn't reuse the outputs of the older version
+func buildStamp() string {
+	stamp := ""
+	if info, ok := debug.ReadBuildInfo(); ok {
+		stamp = info.Main.Version
+	}
+	if exe, err := os.Executable(); err == nil {
+		if info, err := os.Stat(exe); err == nil {
+			stamp = fmt.Sprint(stamp, " ", info.Size(), " ", info.ModTime().UnixNano())
+		}
+	}
+	return stamp
+}
+
+func hashFile(w io.Writer, filePath string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// Writes [v] in a stable form. Unlike fmt, the pointers and the interfaces are followed instead of printing the addresses
+func writeCanonical(w io.Writer, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			io.WriteString(w, "nil")
+			return
+		}
+		if v.Kind() == reflect.Interface {
+			fmt.Fprintf(w, "%s:", v.Elem().Type())
+		}
+		writeCanonical(w, v.Elem())
+
+	case reflect.Struct:
+		fmt.Fprintf(w, "%s{", v.Type())
+		for i := range v.NumField() {
+			fmt.Fprintf(w, "%s:", v.Type().Field(i).Name)
+			writeCanonical(w, v.Field(i))
+			io.WriteString(w, ",")
+		}
+		io.WriteString(w, "}")
+
+	case reflect.Slice, reflect.Array:
+		io.WriteString(w, "[")
+		for i := range v.Len() {
+			writeCanonical(w, v.Index(i))
+			io.WriteString(w, ",")
+		}
+		io.WriteString(w, "]")
+
+	default:
+		fmt.Fprintf(w, "%v", v)
+	}
+}
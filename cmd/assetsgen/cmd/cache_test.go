@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Nidal-Bakir/assets-gen/assetsgen"
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+func writeTestFile(t *testing.T, filePath, content string) {
+	t.Helper()
+	err := os.WriteFile(filePath, []byte(content), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAllCacheKey(t *testing.T) {
+	dir := t.TempDir()
+	imagePath := filepath.Join(dir, "logo.png")
+	bgImagePath := filepath.Join(dir, "bg.png")
+	writeTestFile(t, imagePath, "logo")
+	writeTestFile(t, bgImagePath, "bg")
+
+	newOptions := func() allOptions {
+		options := newAllOptions()
+		options.bg.bgType = "image"
+		options.bg.bgImagePath = bgImagePath
+		// a new pointer every time, the key should follow it instead of using its address
+		maskColor := colorful.Color{R: 1}
+		options.maskColor = &maskColor
+		options.darkBgIcon = assetsgen.NewSolidColorBackground(colorful.Color{})
+		return options
+	}
+
+	key := func(options allOptions, spec assetsgen.TargetSpec) string {
+		t.Helper()
+		k, err := allCacheKey(imagePath, options, spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k
+	}
+
+	base := key(newOptions(), assetsgen.BuiltinTargetsDescription())
+	if again := key(newOptions(), assetsgen.BuiltinTargetsDescription()); again != base {
+		t.Fatalf("the same inputs gave two keys %s and %s", base, again)
+	}
+
+	tests := []struct {
+		name   string
+		change func(options *allOptions, spec *assetsgen.TargetSpec)
+	}{
+		{
+			name:   "option",
+			change: func(options *allOptions, _ *assetsgen.TargetSpec) { options.padding = 0.1 },
+		},
+		{
+			name: "option behind a pointer",
+			change: func(options *allOptions, _ *assetsgen.TargetSpec) {
+				options.maskColor = &colorful.Color{G: 1}
+			},
+		},
+		{
+			name: "option behind an interface",
+			change: func(options *allOptions, _ *assetsgen.TargetSpec) {
+				options.darkBgIcon = assetsgen.NewSolidColorBackground(colorful.Color{R: 1, G: 1, B: 1})
+			},
+		},
+		{
+			name:   "background image",
+			change: func(_ *allOptions, _ *assetsgen.TargetSpec) { writeTestFile(t, bgImagePath, "another bg") },
+		},
+		{
+			name:   "image",
+			change: func(_ *allOptions, _ *assetsgen.TargetSpec) { writeTestFile(t, imagePath, "another logo") },
+		},
+		{
+			name:   "spec",
+			change: func(_ *allOptions, spec *assetsgen.TargetSpec) { spec.Targets[0].FileName = "renamed" },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeTestFile(t, imagePath, "logo")
+			writeTestFile(t, bgImagePath, "bg")
+
+			options, spec := newOptions(), assetsgen.BuiltinTargetsDescription()
+			tt.change(&options, &spec)
+			if key(options, spec) == base {
+				t.Errorf("changing the %s kept the key", tt.name)
+			}
+		})
+	}
+}
+
+// Writes two files, counts the calls
+type fakeGenerate struct {
+	calls int
+	err   error
+}
+
+func (g *fakeGenerate) generate(output assetsgen.OutputSink) error {
+	g.calls++
+	for _, name := range []string{"android/res/a.png", "ios/b.png"} {
+		w, err := output.Create(name)
+		if err != nil {
+			return err
+		}
+		io.WriteString(w, "content of "+name)
+		err = w.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return g.err
+}
+
+func TestOutputCacheThrough(t *testing.T) {
+	cache, err := openOutputCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := &fakeGenerate{}
+	first := assetsgen.NewMemorySink()
+	err = cache.through("key", first, g.generate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second := assetsgen.NewMemorySink()
+	err = cache.through("key", second, g.generate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.calls != 1 {
+		t.Errorf("generate was called %d times, want once", g.calls)
+	}
+	assertSameFiles(t, second, first)
+}
+
+func TestOutputCacheBrokenEntry(t *testing.T) {
+	cache, err := openOutputCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := &fakeGenerate{}
+	err = cache.through("key", assetsgen.NewMemorySink(), g.generate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a file that can't be read, e.g: a link to a deleted file
+	broken := filepath.Join(cache.dir, "key", "ios", "b.png")
+	os.Remove(broken)
+	err = os.Symlink(filepath.Join(cache.dir, "missing"), broken)
+	if err != nil {
+		t.Skip("symlinks are not supported:", err)
+	}
+
+	output := assetsgen.NewMemorySink()
+	err = cache.through("key", output, g.generate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.calls != 2 {
+		t.Errorf("generate was called %d times, want the broken entry to be generated again", g.calls)
+	}
+	if len(output.Files()) != 2 {
+		t.Errorf("got %d files, want 2", len(output.Files()))
+	}
+
+	// the broken entry was deleted and stored again
+	info, err := os.Lstat(broken)
+	if err != nil || !info.Mode().IsRegular() {
+		t.Errorf("the broken entry was not replaced: %v, %v", info, err)
+	}
+}
+
+func TestOutputCacheFailedGenerate(t *testing.T) {
+	cache, err := openOutputCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errGenerate := errors.New("generate failed")
+	g := &fakeGenerate{err: errGenerate}
+	err = cache.through("key", assetsgen.NewMemorySink(), g.generate)
+	if !errors.Is(err, errGenerate) {
+		t.Fatalf("through() error = %v, want %v", err, errGenerate)
+	}
+
+	entries, err := os.ReadDir(cache.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		names := make([]string, len(entries))
+		for i, e := range entries {
+			names[i] = e.Name()
+		}
+		t.Errorf("the failed generate left %s in the cache", strings.Join(names, ", "))
+	}
+}
+
+func assertSameFiles(t *testing.T, got, want *assetsgen.MemorySink) {
+	t.Helper()
+	gotFiles, wantFiles := got.Files(), want.Files()
+	if len(gotFiles) != len(wantFiles) {
+		t.Fatalf("got %d files, want %d", len(gotFiles), len(wantFiles))
+	}
+	for name, data := range wantFiles {
+		if string(gotFiles[name]) != string(data) {
+			t.Errorf("%s is %q, want %q", name, gotFiles[name], data)
+		}
+	}
+}
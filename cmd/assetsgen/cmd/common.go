@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"slices"
@@ -17,15 +18,31 @@ import (
 
 var (
 	ErrInvalidBgType                        = errors.New("invalid bg-type")
+	ErrInvalidGradientSpace                 = errors.New("invalid gradient-space")
+	ErrInvalidResampleFilter                = errors.New("invalid resample filter")
+	ErrInvalidResizeSpace                   = errors.New("invalid resize-space. possible values (linear, srgb)")
+	ErrSharpenOutOfRange                    = errors.New("sharpen-below should be 0 or more, and sharpen-amount between 0..10")
+	ErrInvalidAdaptiveIconShape             = errors.New("invalid auto-fit shape. possible values (safe-zone, circle, squircle, rounded-square, teardrop)")
 	ErrInvalidAndroidFolder                 = errors.New("invalid android folder name. possible values (mipmap, drawable)")
 	ErrInvalidValueRange                    = errors.New("invalid value range")
 	ErrPaddingOutOfRange                    = errors.New("padding should be between 0..1")
 	ErrAlphaThresholdOutOfRange             = errors.New("threshold should be between 0..1 or -1 to disable")
-	ErrInvalidColor                         = errors.New("invalid color. e.g of valid colors #0000FF, #FFFFFF")
+	ErrInvalidColor                         = errors.New("invalid color. e.g of valid colors #0000FF, #FFFFFF, #0000FF80")
 	ErrColorsAndStopsLengthDidNotMatch      = errors.New("the length fo colors should match the length of stops")
 	ErrDidNotFindTheAndroidFolder           = errors.New("did not find the android folder")
 	ErrDidNotFindTheAssetsXcassetsIosFolder = errors.New("did not find the Assets.xcassets ios folder")
 	ErrPleaseSpecifyImagePath               = errors.New("please specify image path")
+	ErrPleaseSpecifyTargetSpec              = errors.New("please specify the targets spec path with --spec")
+	ErrDidNotFindTheAssetsGenOutFolder      = errors.New("did not find the assets_gen_out folder, generate the icons first")
+	ErrInvalidPreviewFormat                 = errors.New("invalid preview format. possible values (png, html)")
+	ErrDidNotFindTheProjectFolders          = errors.New("did not find the android or the ios folders, run the command from the root of the project")
+	ErrIconsAreNotUpToDate                  = errors.New("the icons are not up to date")
+	ErrLintFailed                           = errors.New("the icons don't meet the requirements")
+	ErrZipCanNotBeUsedWithApply             = errors.New("zip can't be used with apply or preview, the files are not in the assets_gen_out folder")
+	ErrNoImagesFound                        = errors.New("no png or jpg images found")
+	ErrSomeImagesFailed                     = errors.New("some of the images failed")
+	ErrDuplicateResourceName                = errors.New("same resource name")
+	ErrImageTooLarge                        = errors.New("the image is too large")
 )
 
 func androidFolderFlag(folderName *assetsgen.AndroidFolderName) *cli.StringFlag {
@@ -65,6 +82,90 @@ func trimWhiteSpaceFlagFn(trimWhiteSpace *bool) *cli.BoolFlag {
 	}
 }
 
+func trimColorFlagFn(trim *assetsgen.TrimOptions) *cli.StringFlag {
+	return &cli.StringFlag{
+		Name:  "trim-color",
+		Usage: "The solid background color around the logo (e.g: #FFFFFF for a JPG logo on white) or auto to detect it from the edges. Used by --trim and --key-out",
+		Action: func(ctx context.Context, c *cli.Command, s string) error {
+			if strings.TrimSpace(s) == "auto" {
+				trim.DetectBorderColor = true
+				return nil
+			}
+
+			color, _, err := assetsgen.ParseHexColor(s)
+			if err != nil {
+				return ErrInvalidColor
+			}
+			trim.BorderColor = &color
+			return nil
+		},
+	}
+}
+
+func trimToleranceFlagFn(tolerance *float64) *cli.FloatFlag {
+	return &cli.FloatFlag{
+		Name:        "trim-tolerance",
+		Value:       0.1,
+		Usage:       "Between [0..1] how far a color can be from --trim-color and still count as background",
+		Destination: tolerance,
+		Validator: func(i float64) error {
+			if i < 0 || i > 1 {
+				return ErrInvalidValueRange
+			}
+			return nil
+		},
+	}
+}
+
+func keyOutFlagFn(keyOut *bool) *cli.BoolFlag {
+	return &cli.BoolFlag{
+		Name:        "key-out",
+		Value:       false,
+		Usage:       "Make the --trim-color background around the logo transparent, so it works with --mask and the adaptive icons",
+		Destination: keyOut,
+	}
+}
+
+func trimAlphaFlagFn(alphaThreshold *float64) *cli.FloatFlag {
+	return &cli.FloatFlag{
+		Name:        "trim-alpha",
+		Value:       0,
+		Usage:       "Between [0..1] --trim also trims the pixels with alpha at or below it, e.g: 0.05 to ignore faint shadows and anti aliasing noise",
+		Destination: alphaThreshold,
+		Validator: func(i float64) error {
+			if i < 0 || i > 1 {
+				return ErrInvalidValueRange
+			}
+			return nil
+		},
+	}
+}
+
+func trimCoverageFlagFn(coverage *float64) *cli.FloatFlag {
+	return &cli.FloatFlag{
+		Name:        "trim-coverage",
+		Value:       1,
+		Usage:       "Between [0..1] --trim to the smallest box that holds this share of the logo, e.g: 0.999 to drop a few stray pixels. 1 for the exact bounds",
+		Destination: coverage,
+		Validator: func(i float64) error {
+			if i < 0 || i > 1 {
+				return ErrInvalidValueRange
+			}
+			return nil
+		},
+	}
+}
+
+func trimFlags(trim *assetsgen.TrimOptions) []cli.Flag {
+	return []cli.Flag{
+		trimColorFlagFn(trim),
+		trimToleranceFlagFn(&trim.Tolerance),
+		trimAlphaFlagFn(&trim.AlphaThreshold),
+		trimCoverageFlagFn(&trim.Coverage),
+		keyOutFlagFn(&trim.KeyOut),
+	}
+}
+
 func outputNameFlagFn(outputName *string, defaultVal string) *cli.StringFlag {
 	return &cli.StringFlag{
 		Name:        "output",
@@ -75,7 +176,28 @@ func outputNameFlagFn(outputName *string, defaultVal string) *cli.StringFlag {
 	}
 }
 
-var bgTypes = []string{"solid-color", "linear-gradient", "radial-gradient", "image"}
+func sanitizeNameFlagFn(sanitizeName *bool) *cli.BoolFlag {
+	return &cli.BoolFlag{
+		Name:        "sanitize-name",
+		Value:       false,
+		Usage:       "Turn an invalid Android resource name into a valid one instead of failing, e.g: \"My-Icon 2\" to \"my_icon_2\"",
+		Destination: sanitizeName,
+	}
+}
+
+// Fails early, before generating anything, on the names that would break aapt2
+func checkAndroidResourceName(name string, sanitize bool) (string, error) {
+	if sanitize {
+		return assetsgen.SanitizeAndroidResourceName(name), nil
+	}
+	err := assetsgen.ValidateAndroidResourceName(name)
+	if err != nil {
+		return name, fmt.Errorf("%w (or use --sanitize-name)", err)
+	}
+	return name, nil
+}
+
+var bgTypes = []string{"solid-color", "linear-gradient", "radial-gradient", "conic-gradient", "diamond-gradient", "image"}
 
 func bgTypeFlagFn(bgType *string) *cli.StringFlag {
 	return &cli.StringFlag{
@@ -106,15 +228,34 @@ func imageBgFlagFn(bgImagePath *string) *cli.StringFlag {
 	}
 }
 
-func solidColorFlagFn(solidBgColor *colorful.Color) *cli.StringFlag {
+func solidColorFlagFn(solidBgColor *colorful.Color, solidBgColorAlpha *float64) *cli.StringFlag {
 	return &cli.StringFlag{
 		Name:  "color",
 		Value: "#FFFFFF",
-		Usage: "The solid background color default to white",
+		Usage: "The solid background color default to white. Use #RRGGBBAA for a translucent color, the alpha is kept only where the target allows transparency",
 		Action: func(ctx context.Context, c *cli.Command, s string) error {
-			color, err := colorful.Hex(s)
+			color, alpha, err := assetsgen.ParseHexColor(s)
+			if err != nil {
+				return ErrInvalidColor
+			}
 			*solidBgColor = color
-			return err
+			*solidBgColorAlpha = alpha
+			return nil
+		},
+	}
+}
+
+func darkColorFlagFn(darkBgIcon *assetsgen.BackgroundIcon) *cli.StringFlag {
+	return &cli.StringFlag{
+		Name:  "dark-color",
+		Usage: "Generate the single size IOS icon set (1024px light and dark icons, iOS 18+) instead of the per size icons, with this background color for the dark icon. Use #RRGGBBAA or #00000000 for a transparent background",
+		Action: func(ctx context.Context, c *cli.Command, s string) error {
+			color, alpha, err := assetsgen.ParseHexColor(s)
+			if err != nil {
+				return ErrInvalidColor
+			}
+			*darkBgIcon = assetsgen.NewTranslucentSolidColorBackground(color, alpha)
+			return nil
 		},
 	}
 }
@@ -124,9 +265,13 @@ func maskColorFlagFn(maskColor **colorful.Color) *cli.StringFlag {
 		Name:  "mask",
 		Usage: "Mask the logo colors",
 		Action: func(ctx context.Context, c *cli.Command, s string) error {
-			color, err := colorful.Hex(s)
+			// the mask is opaque, the alpha of #RRGGBBAA is ignored
+			color, _, err := assetsgen.ParseHexColor(s)
+			if err != nil {
+				return ErrInvalidColor
+			}
 			*maskColor = &color
-			return err
+			return nil
 		},
 	}
 }
@@ -135,16 +280,46 @@ func linearGradientDegreeFlagFn(degree *int) *cli.IntFlag {
 	return &cli.IntFlag{
 		Name:        "degree",
 		Value:       0,
-		Usage:       "The angle of rotation for the linear gradient background",
+		Usage:       "The angle of rotation for the linear gradient background, or the start angle of the conic gradient background",
 		Destination: degree,
 	}
 }
 
-func gradientColorsFlagFn(colors *[]colorful.Color) *cli.StringFlag {
+func gradientCenterXFlagFn(centerX *float64) *cli.FloatFlag {
+	return &cli.FloatFlag{
+		Name:        "center-x",
+		Value:       0.5,
+		Usage:       "Between [0..1] as percentage of the width. The center of the radial, conic and diamond gradient backgrounds",
+		Destination: centerX,
+		Validator: func(i float64) error {
+			if i < 0 || i > 1 {
+				return ErrInvalidValueRange
+			}
+			return nil
+		},
+	}
+}
+
+func gradientCenterYFlagFn(centerY *float64) *cli.FloatFlag {
+	return &cli.FloatFlag{
+		Name:        "center-y",
+		Value:       0.5,
+		Usage:       "Between [0..1] as percentage of the height. The center of the radial, conic and diamond gradient backgrounds",
+		Destination: centerY,
+		Validator: func(i float64) error {
+			if i < 0 || i > 1 {
+				return ErrInvalidValueRange
+			}
+			return nil
+		},
+	}
+}
+
+func gradientColorsFlagFn(colors *[]colorful.Color, alphas *[]float64) *cli.StringFlag {
 	return &cli.StringFlag{
 		Name:  "colors",
 		Value: "#FFFFFF, #000000",
-		Usage: "The gradient background colors, comma separated e.g: #0000FF, #FF0000. You should supply the stops also. The colors count should match the stops",
+		Usage: "The gradient background colors, comma separated e.g: #0000FF, #FF000000. You should supply the stops also. The colors count should match the stops",
 		Action: func(ctx context.Context, c *cli.Command, s string) error {
 			if len(s) == 0 {
 				return nil
@@ -152,13 +327,14 @@ func gradientColorsFlagFn(colors *[]colorful.Color) *cli.StringFlag {
 
 			colorsFromUser := strings.Split(s, ",")
 			*colors = make([]colorful.Color, len(colorsFromUser))
+			*alphas = make([]float64, len(colorsFromUser))
 			for i, colorStr := range colorsFromUser {
-				colorStr = strings.TrimSpace(colorStr)
-				c, err := colorful.Hex(colorStr)
+				c, alpha, err := assetsgen.ParseHexColor(colorStr)
 				if err != nil {
 					return ErrInvalidColor
 				}
 				(*colors)[i] = c
+				(*alphas)[i] = alpha
 			}
 
 			return nil
@@ -169,8 +345,7 @@ func gradientColorsFlagFn(colors *[]colorful.Color) *cli.StringFlag {
 func gradientStopsFlagFn(stops *[]float64) *cli.StringFlag {
 	return &cli.StringFlag{
 		Name:  "stops",
-		Value: "0.0, 1.0",
-		Usage: "The gradient background colors stops, comma separated e.g: 0.0, 1.0. The stops count should match the colors",
+		Usage: "The gradient background colors stops in ascending order, comma separated e.g: 0.0, 1.0. The stops count should match the colors. Spread evenly if not set",
 		Action: func(ctx context.Context, c *cli.Command, s string) error {
 			if len(s) == 0 {
 				return nil
@@ -192,6 +367,18 @@ func gradientStopsFlagFn(stops *[]float64) *cli.StringFlag {
 	}
 }
 
+func gradientSpecFlagFn(gradientSpec *string) *cli.StringFlag {
+	return &cli.StringFlag{
+		Name:        "gradient",
+		Usage:       `A css like gradient background, overrides the other gradient and bg-type flags e.g: "linear-gradient(90deg, #f00 0%, #00f 100%)", "radial-gradient(circle at 30% 30%, #fff, #3040a0)", "conic-gradient(from 90deg, #f00, #00f, #f00)", "diamond-gradient(#fff, #000)"`,
+		Destination: gradientSpec,
+		Validator: func(s string) error {
+			_, err := assetsgen.ParseGradient(s)
+			return err
+		},
+	}
+}
+
 func paddingFlagFn(padding *float64) *cli.FloatFlag {
 	return &cli.FloatFlag{
 		Name:        "padding",
@@ -208,6 +395,55 @@ func paddingFlagFn(padding *float64) *cli.FloatFlag {
 	}
 }
 
+func opticalCenterFlagFn(opticalCentering *float64) *cli.FloatFlag {
+	return &cli.FloatFlag{
+		Name:        "optical-center",
+		Destination: opticalCentering,
+		Value:       0,
+		Usage:       "Between [0..1] moves the logo toward its visual center instead of its bounding box center, e.g: 0.5 for play buttons and letters like L",
+		Validator: func(i float64) error {
+			if i < 0 || i > 1 {
+				return ErrInvalidValueRange
+			}
+			return nil
+		},
+	}
+}
+
+func autoFitFlagFn(autoFit *assetsgen.AdaptiveIconShape) *cli.StringFlag {
+	return &cli.StringFlag{
+		Name:  "auto-fit",
+		Usage: "Scales the adaptive icon logo to fit exactly inside a shape: safe-zone, circle, squircle, rounded-square, teardrop. Use safe-zone to be safe on every launcher",
+		Action: func(ctx context.Context, c *cli.Command, s string) error {
+			shape, err := assetsgen.ParseAdaptiveIconShape(s)
+			if err != nil {
+				return ErrInvalidAdaptiveIconShape
+			}
+			*autoFit = shape
+			return nil
+		},
+	}
+}
+
+func safeZoneReportFlagFn(safeZoneReport *bool) *cli.BoolFlag {
+	return &cli.BoolFlag{
+		Name:        "safe-zone-report",
+		Destination: safeZoneReport,
+		Value:       false,
+		Usage:       "Prints how much of the adaptive icon logo is outside the safe zone and every launcher mask shape",
+	}
+}
+
+func printSafeZoneReport(reports []assetsgen.SafeZoneReport) {
+	for _, r := range reports {
+		status := "fits"
+		if !r.Fits() {
+			status = "clipped"
+		}
+		fmt.Printf("%-15s %-8s %6.2f%% outside, fit scale %.2f\n", r.Shape, status, r.OutsideShare*100, r.FitScale)
+	}
+}
+
 func alphaThresholdFlagFn(alphaThreshold *float64) *cli.FloatFlag {
 	return &cli.FloatFlag{
 		Name:        "alpha-threshold",
@@ -223,61 +459,381 @@ func alphaThresholdFlagFn(alphaThreshold *float64) *cli.FloatFlag {
 	}
 }
 
-func getBgIcon(
-	bgType string,
-	gradientColors []colorful.Color,
-	gradientStops []float64,
-	solidColor colorful.Color,
-	linearGradientDegree int,
-	bgImagePath string,
-) (assetsgen.BackgroundIcon, error) {
+func gradientRadiusFlagFn(radius *float64) *cli.FloatFlag {
+	return &cli.FloatFlag{
+		Name:        "radius",
+		Value:       0.5,
+		Usage:       "As percentage of the minimum axis (w,h) of the image. The radius of the radial gradient background",
+		Destination: radius,
+		Validator: func(i float64) error {
+			if i <= 0 {
+				return ErrInvalidValueRange
+			}
+			return nil
+		},
+	}
+}
+
+func gradientAspectFlagFn(aspect *float64) *cli.FloatFlag {
+	return &cli.FloatFlag{
+		Name:        "aspect",
+		Value:       1,
+		Usage:       "The ratio between the horizontal and the vertical radius of the radial gradient background. 1 is a circle",
+		Destination: aspect,
+		Validator: func(i float64) error {
+			if i <= 0 {
+				return ErrInvalidValueRange
+			}
+			return nil
+		},
+	}
+}
+
+func gradientFocalXFlagFn(focalX *float64) *cli.FloatFlag {
+	return &cli.FloatFlag{
+		Name:        "focal-x",
+		Value:       -1,
+		Usage:       "Between [0..1] as percentage of the width. Where the radial gradient starts, defaults to the center",
+		Destination: focalX,
+		Validator: func(i float64) error {
+			if i < 0 || i > 1 {
+				return ErrInvalidValueRange
+			}
+			return nil
+		},
+	}
+}
+
+func gradientFocalYFlagFn(focalY *float64) *cli.FloatFlag {
+	return &cli.FloatFlag{
+		Name:        "focal-y",
+		Value:       -1,
+		Usage:       "Between [0..1] as percentage of the height. Where the radial gradient starts, defaults to the center",
+		Destination: focalY,
+		Validator: func(i float64) error {
+			if i < 0 || i > 1 {
+				return ErrInvalidValueRange
+			}
+			return nil
+		},
+	}
+}
+
+func gradientSpaceFlagFn(space *assetsgen.GradientSpace) *cli.StringFlag {
+	spaces := make([]string, len(assetsgen.GradientSpaces))
+	for i, v := range assetsgen.GradientSpaces {
+		spaces[i] = string(v)
+	}
+
+	return &cli.StringFlag{
+		Name:  "gradient-space",
+		Value: string(*space),
+		Usage: fmt.Sprint("The color space the gradient colors are blended in: ", strings.Join(spaces, ", ")),
+		Action: func(ctx context.Context, c *cli.Command, s string) error {
+			if !slices.Contains(spaces, s) {
+				return ErrInvalidGradientSpace
+			}
+			*space = assetsgen.GradientSpace(s)
+			return nil
+		},
+	}
+}
+
+func resampleFlagFn(filter *assetsgen.ResampleFilter) *cli.StringFlag {
+	filters := make([]string, len(assetsgen.ResampleFilters))
+	for i, v := range assetsgen.ResampleFilters {
+		filters[i] = string(v)
+	}
+
+	return &cli.StringFlag{
+		Name:  "resample",
+		Value: string(*filter),
+		Usage: fmt.Sprint("The filter used to resize the image to the asset sizes: ", strings.Join(filters, ", "), ". Use nearest for pixel art"),
+		Action: func(ctx context.Context, c *cli.Command, s string) error {
+			f, err := assetsgen.ParseResampleFilter(s)
+			if err != nil {
+				return ErrInvalidResampleFilter
+			}
+			*filter = f
+			return nil
+		},
+	}
+}
+
+func resizeSpaceFlagFn(space *assetsgen.ResizeSpace) *cli.StringFlag {
+	return &cli.StringFlag{
+		Name:  "resize-space",
+		Usage: "The color space the image is resized in: linear (default for the icons, keeps thin strokes bright and avoids dark fringes on transparent edges) or srgb (default for android-asset-gen)",
+		Action: func(ctx context.Context, c *cli.Command, s string) error {
+			parsed, err := assetsgen.ParseResizeSpace(s)
+			if err != nil {
+				return ErrInvalidResizeSpace
+			}
+			*space = parsed
+			return nil
+		},
+	}
+}
+
+func sharpenBelowFlagFn(sharpenBelow *int) *cli.IntFlag {
+	return &cli.IntFlag{
+		Name:        "sharpen-below",
+		Destination: sharpenBelow,
+		Value:       0,
+		Usage:       "Sharpen the outputs smaller than this size in pixels (e.g: 64), 0 to disable",
+		Validator: func(i int) error {
+			if i < 0 {
+				return ErrSharpenOutOfRange
+			}
+			return nil
+		},
+	}
+}
+
+func sharpenAmountFlagFn(sharpenAmount *float64) *cli.FloatFlag {
+	return &cli.FloatFlag{
+		Name:        "sharpen-amount",
+		Destination: sharpenAmount,
+		Value:       0.5,
+		Usage:       "The strength of the sharpening of --sharpen-below, 0.5 is subtle and 1 is strong",
+		Validator: func(i float64) error {
+			if i < 0 || i > 10 {
+				return ErrSharpenOutOfRange
+			}
+			return nil
+		},
+	}
+}
+
+func resizeFlags(options *assetsgen.ResizeOptions) []cli.Flag {
+	return []cli.Flag{
+		resampleFlagFn(&options.Filter),
+		resizeSpaceFlagFn(&options.Space),
+		sharpenBelowFlagFn(&options.SharpenBelow),
+		sharpenAmountFlagFn(&options.SharpenAmount),
+	}
+}
+
+type bgIconOptions struct {
+	bgType               string
+	bgImagePath          string
+	linearGradientDegree int
+	solidColor           colorful.Color
+	solidColorAlpha      float64
+	gradientColors       []colorful.Color
+	gradientAlphas       []float64
+	gradientStops        []float64
+	gradientSpace        assetsgen.GradientSpace
+	// css like gradient, overrides the other gradient options
+	gradientSpec string
+
+	// between [0..1] as percentage of the width and height of the image
+	gradientCenterX float64
+	gradientCenterY float64
+
+	gradientRadius float64
+	gradientAspect float64
+	// -1 to use the center
+	gradientFocalX float64
+	gradientFocalY float64
+}
+
+func newBgIconOptions() bgIconOptions {
+	return bgIconOptions{
+		solidColor:      colorful.Color{R: 1, G: 1, B: 1},
+		solidColorAlpha: 1,
+		gradientColors:  []colorful.Color{{R: 1, G: 1, B: 1}, {R: 0, G: 0, B: 0}},
+		gradientAlphas:  []float64{1, 1},
+		gradientSpace:   assetsgen.GradientSpaceHcl,
+		gradientCenterX: 0.5,
+		gradientCenterY: 0.5,
+		gradientRadius:  0.5,
+		gradientAspect:  1,
+		gradientFocalX:  -1,
+		gradientFocalY:  -1,
+	}
+}
+
+func bgIconFlags(bg *bgIconOptions) []cli.Flag {
+	return []cli.Flag{
+		bgTypeFlagFn(&bg.bgType),
+		gradientSpecFlagFn(&bg.gradientSpec),
+		solidColorFlagFn(&bg.solidColor, &bg.solidColorAlpha),
+		gradientColorsFlagFn(&bg.gradientColors, &bg.gradientAlphas),
+		gradientStopsFlagFn(&bg.gradientStops),
+		gradientSpaceFlagFn(&bg.gradientSpace),
+		linearGradientDegreeFlagFn(&bg.linearGradientDegree),
+		gradientCenterXFlagFn(&bg.gradientCenterX),
+		gradientCenterYFlagFn(&bg.gradientCenterY),
+		gradientRadiusFlagFn(&bg.gradientRadius),
+		gradientAspectFlagFn(&bg.gradientAspect),
+		gradientFocalXFlagFn(&bg.gradientFocalX),
+		gradientFocalYFlagFn(&bg.gradientFocalY),
+		imageBgFlagFn(&bg.bgImagePath),
+	}
+}
+
+func (bg bgIconOptions) radialGradientOptions() assetsgen.RadialGradientOptions {
+	option := assetsgen.RadialGradientOptions{
+		Center: assetsgen.GradientPoint{X: bg.gradientCenterX, Y: bg.gradientCenterY},
+		Radius: bg.gradientRadius,
+		Aspect: bg.gradientAspect,
+	}
+
+	if bg.gradientFocalX >= 0 || bg.gradientFocalY >= 0 {
+		focal := option.Center
+		if bg.gradientFocalX >= 0 {
+			focal.X = bg.gradientFocalX
+		}
+		if bg.gradientFocalY >= 0 {
+			focal.Y = bg.gradientFocalY
+		}
+		option.Focal = &focal
+	}
+
+	return option
+}
+
+func getBgIcon(bg bgIconOptions) (assetsgen.BackgroundIcon, error) {
 	var BgIcon assetsgen.BackgroundIcon
 
-	switch bgType {
+	if len(bg.gradientSpec) != 0 {
+		spec, err := assetsgen.ParseGradient(bg.gradientSpec)
+		if err != nil {
+			return nil, err
+		}
+		if len(spec.Space) == 0 {
+			spec.Space = bg.gradientSpace
+		}
+		return spec.BackgroundIcon(), nil
+	}
+
+	switch bg.bgType {
 	case "solid-color":
-		BgIcon = assetsgen.NewSolidColorBackground(solidColor)
+		BgIcon = assetsgen.NewTranslucentSolidColorBackground(bg.solidColor, bg.solidColorAlpha)
 
 	case "linear-gradient":
-		table, err := generateGradientTable(gradientColors, gradientStops)
+		table, err := generateGradientTable(bg.gradientColors, bg.gradientAlphas, bg.gradientStops)
 		if err != nil {
 			return nil, err
 		}
-		BgIcon = assetsgen.NewLinearGradientBackground(table, linearGradientDegree)
+		BgIcon = assetsgen.NewLinearGradientBackground(table, bg.linearGradientDegree)
 
 	case "radial-gradient":
-		table, err := generateGradientTable(gradientColors, gradientStops)
+		table, err := generateGradientTable(bg.gradientColors, bg.gradientAlphas, bg.gradientStops)
 		if err != nil {
 			return nil, err
 		}
-		BgIcon = assetsgen.NewRadialGradientBackground(table)
+		BgIcon = assetsgen.NewRadialGradientBackground(table, bg.radialGradientOptions())
+
+	case "conic-gradient":
+		table, err := generateGradientTable(bg.gradientColors, bg.gradientAlphas, bg.gradientStops)
+		if err != nil {
+			return nil, err
+		}
+		BgIcon = assetsgen.NewConicGradientBackground(table, bg.linearGradientDegree, bg.gradientCenterX, bg.gradientCenterY)
+
+	case "diamond-gradient":
+		table, err := generateGradientTable(bg.gradientColors, bg.gradientAlphas, bg.gradientStops)
+		if err != nil {
+			return nil, err
+		}
+		BgIcon = assetsgen.NewDiamondGradientBackground(table, bg.gradientCenterX, bg.gradientCenterY)
 
 	case "image":
-		BgIcon = assetsgen.NewImageBackground(bgImagePath)
+		BgIcon = assetsgen.NewImageBackground(bg.bgImagePath)
 
 	default:
 		panic("we should not be here")
 	}
 
-	return BgIcon, nil
+	return assetsgen.WithGradientSpace(BgIcon, bg.gradientSpace), nil
 }
 
-func generateGradientTable(colors []colorful.Color, stops []float64) (assetsgen.GradientTable, error) {
-	if len(colors) != len(stops) {
+// [stops] nil to spread the colors evenly
+func generateGradientTable(colors []colorful.Color, alphas []float64, stops []float64) (assetsgen.GradientTable, error) {
+	if stops != nil && len(colors) != len(stops) {
 		return nil, ErrColorsAndStopsLengthDidNotMatch
 	}
 
 	table := make(assetsgen.GradientTable, len(colors))
 
 	for i, c := range colors {
+		pos := math.NaN()
+		if stops != nil {
+			pos = stops[i]
+		}
 		table[i] = assetsgen.GradientTableItem{
-			Col: c,
-			Pos: stops[i],
+			Col:          c,
+			Pos:          pos,
+			Transparency: 1 - alphas[i],
 		}
 	}
 
+	table = table.FillMissingPositions()
+	err := table.Validate()
+	if err != nil {
+		return nil, err
+	}
+
 	return table, nil
 }
 
+func previewFlagFn(preview *bool) *cli.BoolFlag {
+	return &cli.BoolFlag{
+		Name:        "preview",
+		Value:       false,
+		Usage:       "Render a contact sheet of the generated icons under the launcher masks, status bars and store corners, to attach to the reviews",
+		Destination: preview,
+	}
+}
+
+func previewFormatFlagFn(format *assetsgen.PreviewFormat) *cli.StringFlag {
+	return &cli.StringFlag{
+		Name:  "format",
+		Value: string(*format),
+		Usage: "The format of the preview: png for a single image, html for a page with an image per icon",
+		Action: func(ctx context.Context, c *cli.Command, s string) error {
+			f, err := assetsgen.ParsePreviewFormat(s)
+			if err != nil {
+				return ErrInvalidPreviewFormat
+			}
+			*format = f
+			return nil
+		},
+	}
+}
+
+func tileSizeFlagFn(tileSize *int) *cli.IntFlag {
+	return &cli.IntFlag{
+		Name:        "tile-size",
+		Value:       192,
+		Usage:       "The size of every icon in the preview in pixels",
+		Destination: tileSize,
+		Validator: func(i int) error {
+			if i < 16 || i > 1024 {
+				return ErrInvalidValueRange
+			}
+			return nil
+		},
+	}
+}
+
+func toleranceFlagFn(tolerance *float64) *cli.FloatFlag {
+	return &cli.FloatFlag{
+		Name:        "tolerance",
+		Value:       assetsgen.DefaultVerifyTolerance,
+		Usage:       "The max perceptual difference (CIE76 ΔE) between two pixels to be seen as the same, around 2.3 is just noticeable",
+		Destination: tolerance,
+		Validator: func(f float64) error {
+			if f <= 0 {
+				return ErrInvalidValueRange
+			}
+			return nil
+		},
+	}
+}
+
 func applyFlagFn(apply *bool) *cli.BoolFlag {
 	return &cli.BoolFlag{
 		Name:        "apply",
@@ -287,6 +843,59 @@ func applyFlagFn(apply *bool) *cli.BoolFlag {
 	}
 }
 
+func targetSpecFlagFn(name string, specPath *string) *cli.StringFlag {
+	return &cli.StringFlag{
+		Name:        name,
+		Usage:       "The JSON or YAML spec of the custom targets to generate, see the targets command for the format",
+		Destination: specPath,
+	}
+}
+
+func zipFlagFn(zipPath *string) *cli.StringFlag {
+	return &cli.StringFlag{
+		Name:        "zip",
+		Usage:       "Write the generated files to a zip archive at this path instead of the assets_gen_out folder",
+		Destination: zipPath,
+	}
+}
+
+// Runs [fn] with the output the generators should write to. Nil for the assets_gen_out folder when [zipPath] is empty,
+// otherwise a zip archive that is deleted if [fn] fails. Recorded for the diff of [runWatching] in watch mode
+func withOutputSink(ctx context.Context, zipPath string, fn func(output assetsgen.OutputSink) error) error {
+	if zipPath == "" {
+		return withRecorder(ctx, nil, fn)
+	}
+
+	f, err := os.Create(zipPath)
+	if err != nil {
+		return err
+	}
+
+	zipSink := assetsgen.NewZipSink(f)
+	err = errors.Join(withRecorder(ctx, zipSink, fn), zipSink.Close(), f.Close())
+	if err != nil {
+		os.Remove(zipPath)
+		return err
+	}
+
+	fmt.Println("generated:", zipPath)
+	return nil
+}
+
+// Runs [fn] with the assets_gen_out folder the generators fall back to when [output] is nil, for the code that wraps the output
+func orDefaultOutput(output assetsgen.OutputSink, fn func(output assetsgen.OutputSink) error) error {
+	if output != nil {
+		return fn(output)
+	}
+
+	dirSink, err := assetsgen.NewDirSink("")
+	if err != nil {
+		return err
+	}
+	defer dirSink.Close()
+	return fn(dirSink)
+}
+
 // app/
 func getAndroidAppDir() (string, error) {
 	// android native project
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+func TestMaskColorFlag(t *testing.T) {
+	var maskColor *colorful.Color
+	flag := maskColorFlagFn(&maskColor)
+
+	for _, s := range []string{"red", "#12345", "FF0000", ""} {
+		err := flag.Action(context.Background(), nil, s)
+		if !errors.Is(err, ErrInvalidColor) {
+			t.Errorf("%q: err = %v, want %v", s, err, ErrInvalidColor)
+		}
+		if maskColor != nil {
+			t.Fatalf("%q: the mask color is set to %v", s, *maskColor)
+		}
+	}
+
+	err := flag.Action(context.Background(), nil, "#FF000080")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if maskColor == nil || *maskColor != (colorful.Color{R: 1}) {
+		t.Errorf("the mask color is %v, want #FF0000", maskColor)
+	}
+}
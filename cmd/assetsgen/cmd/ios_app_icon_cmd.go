@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"github.com/Nidal-Bakir/assets-gen/assetsgen"
 	"github.com/lucasb-eyer/go-colorful"
@@ -14,18 +15,19 @@ import (
 func IosAppIcon() *cli.Command {
 	var imagePath string
 
-	var bgType string
-	var bgImagePath string
-	var linearGradientDegree int
-	var solidColor = colorful.Color{R: 1, G: 1, B: 1}
-	var gradientColors = []colorful.Color{{R: 1, G: 1, B: 1}, {R: 0, G: 0, B: 0}}
-	var gradientStops = []float64{0.0, 1.0}
+	var bg = newBgIconOptions()
 
 	var maskColor *colorful.Color
+	var darkBgIcon assetsgen.BackgroundIcon
 	var trimWhiteSpace bool
+	var trim assetsgen.TrimOptions
+	var opticalCentering float64
 	var alphaThreshold float64
 	var padding float64
 	var apply bool
+	var zipPath string
+	var watch bool
+	resize := assetsgen.DefaultResizeOptions()
 
 	action := func(ctx context.Context, c *cli.Command) error {
 		if b := isPathExist(imagePath); !b {
@@ -35,33 +37,46 @@ func IosAppIcon() *cli.Command {
 			return assetsgen.ErrFileNotFound
 		}
 
-		bgIcon, err := getBgIcon(bgType, gradientColors, gradientStops, solidColor, linearGradientDegree, bgImagePath)
-		if err != nil {
-			return err
+		if zipPath != "" && apply {
+			return ErrZipCanNotBeUsedWithApply
 		}
 
-		err = assetsgen.GenerateAppIconForIos(
-			imagePath,
-			assetsgen.IosAppIconOptions{
-				BgIcon:         bgIcon,
-				Padding:        padding,
-				AlphaThreshold: alphaThreshold,
-				TrimWhiteSpace: trimWhiteSpace,
-				MaskColor:      maskColor,
-			},
-		)
-		if err != nil {
-			return err
-		}
+		return runWatching(ctx, watch, watchedFiles(imagePath, bg.bgImagePath), func(ctx context.Context) error {
+			bgIcon, err := getBgIcon(bg)
+			if err != nil {
+				return err
+			}
 
-		if apply {
-			err = applyIosAppIcon()
+			err = withOutputSink(ctx, zipPath, func(output assetsgen.OutputSink) error {
+				return assetsgen.GenerateAppIconForIos(
+					imagePath,
+					assetsgen.IosAppIconOptions{
+						BgIcon:           bgIcon,
+						Padding:          padding,
+						AlphaThreshold:   alphaThreshold,
+						TrimWhiteSpace:   trimWhiteSpace,
+						Trim:             trim,
+						OpticalCentering: opticalCentering,
+						MaskColor:        maskColor,
+						DarkBgIcon:       darkBgIcon,
+						Resize:           resize,
+						Output:           output,
+					},
+				)
+			})
 			if err != nil {
 				return err
 			}
-		}
 
-		return nil
+			if apply {
+				err = applyIosAppIcon()
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
 	}
 
 	usageText := `ios-app-icon [command [command options]] <image path>
@@ -70,7 +85,9 @@ examples:
 	iai "./app_icon.png"
 	iai -bg linear-gradient --degree 90 --colors "#FF0000, #00FF00, #0000FF" --stops "0.0, 0.5, 1.0" "./app_icon.png"
 	iai --color "#0000FF" "./app_icon.png"
-	iai --apply -p 0.1 --trim "./app_icon.png"`
+	iai --apply -p 0.1 --trim "./app_icon.png"
+	iai --zip "ios_icons.zip" "./app_icon.png"
+	iai --watch "./app_icon.png"`
 
 	return &cli.Command{
 		Name:      "ios-app-icon",
@@ -81,19 +98,26 @@ examples:
 		Arguments: []cli.Argument{
 			imageArg(&imagePath),
 		},
-		Flags: []cli.Flag{
-			paddingFlagFn(&padding),
-			alphaThresholdFlagFn(&alphaThreshold),
-			bgTypeFlagFn(&bgType),
-			solidColorFlagFn(&solidColor),
-			gradientColorsFlagFn(&gradientColors),
-			gradientStopsFlagFn(&gradientStops),
-			linearGradientDegreeFlagFn(&linearGradientDegree),
-			imageBgFlagFn(&bgImagePath),
-			trimWhiteSpaceFlagFn(&trimWhiteSpace),
-			maskColorFlagFn(&maskColor),
-			applyFlagFn(&apply),
-		},
+		Flags: slices.Concat(
+			[]cli.Flag{
+				paddingFlagFn(&padding),
+				opticalCenterFlagFn(&opticalCentering),
+				alphaThresholdFlagFn(&alphaThreshold),
+			},
+			bgIconFlags(&bg),
+			resizeFlags(&resize),
+			[]cli.Flag{
+				trimWhiteSpaceFlagFn(&trimWhiteSpace),
+			},
+			trimFlags(&trim),
+			[]cli.Flag{
+				maskColorFlagFn(&maskColor),
+				darkColorFlagFn(&darkBgIcon),
+				applyFlagFn(&apply),
+				zipFlagFn(&zipPath),
+				watchFlagFn(&watch),
+			},
+		),
 	}
 }
 
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+
+	"github.com/Nidal-Bakir/assets-gen/assetsgen"
+	"github.com/urfave/cli/v3"
+)
+
+func Lint() *cli.Command {
+	var project bool
+	var notificationIcons string
+
+	action := func(ctx context.Context, c *cli.Command) error {
+		dirs := assetsgen.OutputLintDirs("")
+		if project {
+			dirs = projectLintDirs()
+			if dirs == (assetsgen.LintDirs{}) {
+				return ErrDidNotFindTheProjectFolders
+			}
+		} else if b := isPathExist(assetsgen.RootFolderName); !b {
+			return ErrDidNotFindTheAssetsGenOutFolder
+		}
+
+		dirs.NotificationIcons = notificationIcons
+
+		issues, err := assetsgen.Lint(dirs)
+		if err != nil {
+			return err
+		}
+
+		for _, issue := range issues {
+			fmt.Println(issue)
+		}
+
+		if assetsgen.HasLintErrors(issues) {
+			return ErrLintFailed
+		}
+		if len(issues) == 0 {
+			fmt.Println("no issues found")
+		}
+		return nil
+	}
+
+	usageText := `lint [command options]
+
+examples:
+	lint
+	lint --project
+	lint --project --notification-icons "*/ic_notification.png"`
+
+	return &cli.Command{
+		Name:      "lint",
+		UsageText: usageText,
+		Usage:     "Check the generated icons in " + assetsgen.RootFolderName + " (or the project's icons) against the Google Play, App Store and Android requirements",
+		Action:    action,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:        "project",
+				Value:       false,
+				Usage:       "Lint the icons of the android, ios or flutter project in the working directory instead of the generated ones",
+				Destination: &project,
+			},
+			&cli.StringFlag{
+				Name:        "notification-icons",
+				Usage:       "The glob of the notification icons in the android res folder. Defaults to \"" + assetsgen.DefaultNotificationIconsPattern + "\"",
+				Destination: &notificationIcons,
+				Validator: func(s string) error {
+					_, err := path.Match(s, "")
+					return err
+				},
+			},
+		},
+	}
+}
+
+// The folders of the project where --apply moves the files to, the platforms that are not found are left empty
+func projectLintDirs() assetsgen.LintDirs {
+	var dirs assetsgen.LintDirs
+	if resDir, err := getAndroidResDir(); err == nil {
+		dirs.AndroidRes = resDir
+	}
+	if mainDir, err := getAndroidMainDir(); err == nil {
+		dirs.AndroidMain = mainDir
+	}
+	if xcassetsDir, err := getIosXcassets(); err == nil {
+		dirs.IosAppIconSet = filepath.Join(xcassetsDir, "AppIcon.appiconset")
+	}
+	return dirs
+}
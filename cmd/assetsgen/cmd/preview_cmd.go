@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Nidal-Bakir/assets-gen/assetsgen"
+	"github.com/urfave/cli/v3"
+)
+
+func Preview() *cli.Command {
+	var outputName string
+	var format = assetsgen.PreviewFormatPng
+	var tileSize int
+
+	action := func(ctx context.Context, c *cli.Command) error {
+		if b := isPathExist(assetsgen.RootFolderName); !b {
+			return ErrDidNotFindTheAssetsGenOutFolder
+		}
+
+		previewPath, err := assetsgen.GeneratePreview(
+			assetsgen.PreviewOptions{
+				Format:         format,
+				TileSize:       tileSize,
+				OutputFileName: outputName,
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("preview:", previewPath)
+		return nil
+	}
+
+	usageText := `preview [command options]
+
+examples:
+	preview
+	preview --format html --tile-size 128 -o "icons_review"`
+
+	return &cli.Command{
+		Name:      "preview",
+		Aliases:   []string{"pv"},
+		UsageText: usageText,
+		Usage:     "Render a contact sheet of the generated icons in " + assetsgen.RootFolderName + " under the launcher masks, status bars and store corners",
+		Action:    action,
+		Flags: []cli.Flag{
+			previewFormatFlagFn(&format),
+			tileSizeFlagFn(&tileSize),
+			outputNameFlagFn(&outputName, "preview"),
+		},
+	}
+}
+
+// Renders the preview of the files just generated. With --apply the out folder is deleted, so the preview is moved to the working directory
+func generatePreview(format assetsgen.PreviewFormat, apply bool) error {
+	previewPath, err := assetsgen.GeneratePreview(assetsgen.PreviewOptions{Format: format})
+	if err != nil {
+		return err
+	}
+
+	if apply {
+		err = os.Rename(previewPath, filepath.Base(previewPath))
+		if err != nil {
+			return err
+		}
+		previewPath = filepath.Base(previewPath)
+	}
+
+	fmt.Println("preview:", previewPath)
+	return nil
+}
@@ -0,0 +1,307 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Nidal-Bakir/assets-gen/assetsgen"
+	"github.com/urfave/cli/v3"
+	_ "golang.org/x/image/bmp"
+)
+
+//go:embed serve_index.html
+var serveIndexHtml []byte
+
+// The max size of an upload, the image and the options
+const serveMaxUploadSize = 32 << 20
+
+// The max width*height of an image, checked before it's decoded. A small compressed upload can decode to gigabytes
+const serveMaxPixels = 8192 * 8192
+
+// How many generations run at once, the others wait for their turn. Each one holds the decoded image and its
+// outputs in memory, and already uses all the cores
+const serveMaxGenerations = 2
+
+// serve
+func Serve() *cli.Command {
+	var host string
+	var port int
+
+	action := func(ctx context.Context, c *cli.Command) error {
+		addr := net.JoinHostPort(host, strconv.Itoa(port))
+		server := &http.Server{
+			Addr:              addr,
+			Handler:           newServeMux(),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		go func() {
+			<-ctx.Done()
+			server.Close()
+		}()
+
+		fmt.Printf("serving on http://%s\n", addr)
+		err := server.ListenAndServe()
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	}
+
+	usageText := `serve [command options]
+
+examples:
+	serve
+	serve --port 9000
+	serve --host 0.0.0.0 --port 9000`
+
+	return &cli.Command{
+		Name:      "serve",
+		UsageText: usageText,
+		Usage:     "Serve a web page to generate the icons with a live preview, and the same generation over HTTP",
+		Action:    action,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "host",
+				Value:       "127.0.0.1",
+				Usage:       "The address to listen on, 0.0.0.0 to share it on the network",
+				Destination: &host,
+			},
+			&cli.IntFlag{
+				Name:        "port",
+				Value:       8080,
+				Destination: &port,
+			},
+		},
+	}
+}
+
+// GET / the web page
+// POST /api/generate the zip of all the icons
+// POST /api/preview the preview sheet PNG of all the icons
+//
+// The POST requests are multipart forms with the "image" file and the "options" JSON, see [serveOptions]
+func newServeMux() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(serveIndexHtml)
+	})
+
+	generations := make(chan struct{}, serveMaxGenerations)
+
+	mux.HandleFunc("POST /api/generate", handleGeneration(generations, func(w http.ResponseWriter, src *assetsgen.SourceImage, options allOptions) {
+		// buffered to answer with an error status if the generation fails
+		var buf bytes.Buffer
+		zipSink := assetsgen.NewZipSink(&buf)
+		err := errors.Join(options.generate(src, zipSink), zipSink.Close())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+assetsgen.RootFolderName+`.zip"`)
+		buf.WriteTo(w)
+	}))
+
+	mux.HandleFunc("POST /api/preview", handleGeneration(generations, func(w http.ResponseWriter, src *assetsgen.SourceImage, options allOptions) {
+		sink := assetsgen.NewMemorySink()
+		err := options.generate(src, sink)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		var buf bytes.Buffer
+		err = assetsgen.RenderPreview(&buf, sink.FS(), assetsgen.PreviewOptions{})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "image/png")
+		buf.WriteTo(w)
+	}))
+
+	return mux
+}
+
+// The JSON options of the API, the counterpart of the flags of the all command
+type serveOptions struct {
+	// A color (#RRGGBB or #RRGGBBAA) or a CSS like gradient, e.g: linear-gradient(90deg, #f00, #00f)
+	Background       string  `json:"background"`
+	Padding          float64 `json:"padding"`
+	CornerRadius     float64 `json:"cornerRadius"`
+	Trim             bool    `json:"trim"`
+	OpticalCentering float64 `json:"opticalCentering"`
+	// -1 to disable
+	AlphaThreshold float64 `json:"alphaThreshold"`
+	// Empty to disable
+	AutoFit string `json:"autoFit"`
+	// Empty to keep the colors of the logo
+	MaskColor string `json:"maskColor"`
+	// Empty to not generate the dark iOS icon
+	DarkColor string `json:"darkColor"`
+}
+
+// The same defaults as the flags
+func newServeOptions() serveOptions {
+	return serveOptions{
+		Background:     "#FFFFFF",
+		CornerRadius:   1,
+		AlphaThreshold: 0.5,
+	}
+}
+
+// Parses the request and runs [generate] once one of the [generations] slots is free. The requests wait for
+// their turn with the image not decoded yet, so the waiting ones only hold their uploads
+func handleGeneration(generations chan struct{}, generate func(w http.ResponseWriter, src *assetsgen.SourceImage, options allOptions)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, serveMaxUploadSize)
+
+		file, _, err := r.FormFile("image")
+		if err != nil {
+			http.Error(w, ErrPleaseSpecifyImagePath.Error(), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		options, err := parseServeRequest(r, file)
+		if err != nil {
+			status := http.StatusBadRequest
+			if errors.Is(err, ErrImageTooLarge) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		select {
+		case generations <- struct{}{}:
+			defer func() { <-generations }()
+		case <-r.Context().Done():
+			// the client is gone
+			return
+		}
+
+		src, err := decodeServeImage(file)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		generate(w, src, options)
+	}
+}
+
+// The image is checked against [serveMaxPixels] from its header only, then [file] is rewound for the decode
+func parseServeRequest(r *http.Request, file io.ReadSeeker) (allOptions, error) {
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return allOptions{}, fmt.Errorf("%w: %w", assetsgen.ErrUnsupportedFileType, err)
+	}
+	if config.Width*config.Height > serveMaxPixels {
+		return allOptions{}, fmt.Errorf("%w: %dx%d, the max is %d pixels", ErrImageTooLarge, config.Width, config.Height, serveMaxPixels)
+	}
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		return allOptions{}, err
+	}
+
+	req := newServeOptions()
+	if s := r.FormValue("options"); s != "" {
+		decoder := json.NewDecoder(strings.NewReader(s))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&req)
+		if err != nil {
+			return allOptions{}, err
+		}
+	}
+
+	return req.allOptions()
+}
+
+func decodeServeImage(file io.Reader) (*assetsgen.SourceImage, error) {
+	img, format, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", assetsgen.ErrUnsupportedFileType, err)
+	}
+	// the format picks the encoder of the outputs, like the extension of the image path
+	return assetsgen.NewSourceImage(img, fmt.Sprint("logo.", format))
+}
+
+func (o serveOptions) allOptions() (allOptions, error) {
+	options := newAllOptions()
+	options.bg.bgType = "solid-color"
+
+	if o.Padding < 0 || o.Padding > 1 {
+		return options, ErrPaddingOutOfRange
+	}
+	if o.CornerRadius < 0 || o.CornerRadius > 1 || o.OpticalCentering < 0 || o.OpticalCentering > 1 {
+		return options, ErrInvalidValueRange
+	}
+	if o.AlphaThreshold != -1 && (o.AlphaThreshold < 0 || o.AlphaThreshold > 1) {
+		return options, ErrAlphaThresholdOutOfRange
+	}
+	options.padding = o.Padding
+	options.roundedCornerPercentRadius = o.CornerRadius
+	options.trimWhiteSpace = o.Trim
+	options.opticalCentering = o.OpticalCentering
+	options.alphaThreshold = o.AlphaThreshold
+
+	if strings.HasPrefix(strings.TrimSpace(o.Background), "#") {
+		color, alpha, err := assetsgen.ParseHexColor(o.Background)
+		if err != nil {
+			return options, ErrInvalidColor
+		}
+		options.bg.solidColor, options.bg.solidColorAlpha = color, alpha
+	} else {
+		// checked here to answer with a 400, the generation parses it again
+		_, err := assetsgen.ParseGradient(o.Background)
+		if err != nil {
+			return options, err
+		}
+		options.bg.gradientSpec = o.Background
+	}
+
+	if o.AutoFit != "" {
+		shape, err := assetsgen.ParseAdaptiveIconShape(o.AutoFit)
+		if err != nil {
+			return options, ErrInvalidAdaptiveIconShape
+		}
+		options.autoFit = shape
+	}
+
+	if o.MaskColor != "" {
+		color, _, err := assetsgen.ParseHexColor(o.MaskColor)
+		if err != nil {
+			return options, ErrInvalidColor
+		}
+		options.maskColor = &color
+	}
+
+	if o.DarkColor != "" {
+		color, alpha, err := assetsgen.ParseHexColor(o.DarkColor)
+		if err != nil {
+			return options, ErrInvalidColor
+		}
+		options.darkBgIcon = assetsgen.NewTranslucentSolidColorBackground(color, alpha)
+	}
+
+	return options, nil
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"maps"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func serveRequest(t *testing.T, target string, img []byte, options string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("image", "logo.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write(img)
+	if options != "" {
+		mw.WriteField("options", options)
+	}
+	err = mw.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, target, &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+func testLogoPng(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	for y := 16; y < 48; y++ {
+		for x := 16; x < 48; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: 51, G: 102, B: 204, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// The signature and the IHDR chunk of a png, with no pixels at all
+func pngHeaderOnly(w, h uint32) []byte {
+	ihdr := binary.BigEndian.AppendUint32(nil, w)
+	ihdr = binary.BigEndian.AppendUint32(ihdr, h)
+	// 8 bits RGBA, the default compression, filter and no interlace
+	ihdr = append(ihdr, 8, 6, 0, 0, 0)
+
+	chunk := append([]byte("IHDR"), ihdr...)
+	data := []byte("\x89PNG\r\n\x1a\n")
+	data = binary.BigEndian.AppendUint32(data, uint32(len(ihdr)))
+	data = append(data, chunk...)
+	return binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(chunk))
+}
+
+func TestServeRejects(t *testing.T) {
+	tests := []struct {
+		name       string
+		img        []byte
+		options    string
+		wantStatus int
+	}{
+		{
+			// a decode would fail on the missing pixels with a 400
+			name:       "too large from the header",
+			img:        pngHeaderOnly(10000, 10000),
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "not an image",
+			img:        []byte("not an image"),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown option",
+			options:    `{"radius": 0.5}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "padding out of range",
+			options:    `{"padding": 2}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid color",
+			options:    `{"background": "#12345"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid gradient",
+			options:    `{"background": "linear-gradient(90deg, #f00 0%, nope)"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid auto fit",
+			options:    `{"autoFit": "star"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	mux := newServeMux()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := tt.img
+			if img == nil {
+				img = testLogoPng(t)
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, serveRequest(t, "/api/generate", img, tt.options))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+		})
+	}
+}
+
+func TestServeGenerateZip(t *testing.T) {
+	options := `{"background": "linear-gradient(90deg, #f00, #00f)", "padding": 0.1, "darkColor": "#000000"}`
+	w := httptest.NewRecorder()
+	newServeMux().ServeHTTP(w, serveRequest(t, "/api/generate", testLogoPng(t), options))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/zip" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	body := w.Body.Bytes()
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := map[string]bool{}
+	for _, f := range zr.File {
+		names[f.Name] = true
+	}
+	for _, want := range []string{
+		"android/res/mipmap-xxxhdpi/ic_launcher.png",
+		"android/res/mipmap-anydpi-v26/ic_launcher.xml",
+		"ios/Assets.xcassets/AppIcon.appiconset/Contents.json",
+	} {
+		if !names[want] {
+			t.Errorf("the zip has no %s, it has %s", want, strings.Join(slices.Sorted(maps.Keys(names)), ", "))
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/Nidal-Bakir/assets-gen/assetsgen"
+	"github.com/lucasb-eyer/go-colorful"
+	"github.com/urfave/cli/v3"
+)
+
+// targets (tg)
+func Targets() *cli.Command {
+	var imagePath string
+	var specPath string
+	var printBuiltin bool
+
+	var bg = newBgIconOptions()
+
+	var maskColor *colorful.Color
+	var trimWhiteSpace bool
+	var trim assetsgen.TrimOptions
+	var opticalCentering float64
+	var alphaThreshold float64
+	var padding float64
+	var zipPath string
+	var watch bool
+	resize := assetsgen.DefaultResizeOptions()
+
+	action := func(ctx context.Context, c *cli.Command) error {
+		if printBuiltin {
+			out, err := json.MarshalIndent(assetsgen.BuiltinTargetsDescription(), "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		}
+
+		if len(specPath) == 0 {
+			return ErrPleaseSpecifyTargetSpec
+		}
+		if b := isPathExist(imagePath); !b {
+			if len(imagePath) == 0 {
+				return ErrPleaseSpecifyImagePath
+			}
+			return assetsgen.ErrFileNotFound
+		}
+
+		return runWatching(ctx, watch, watchedFiles(imagePath, specPath, bg.bgImagePath), func(ctx context.Context) error {
+			spec, err := assetsgen.LoadTargetSpec(specPath)
+			if err != nil {
+				return err
+			}
+
+			bgIcon, err := getBgIcon(bg)
+			if err != nil {
+				return err
+			}
+
+			return withOutputSink(ctx, zipPath, func(output assetsgen.OutputSink) error {
+				return assetsgen.GenerateTargets(
+					imagePath,
+					spec,
+					assetsgen.TargetOptions{
+						AlphaThreshold:   alphaThreshold,
+						BgIcon:           bgIcon,
+						Padding:          padding,
+						TrimWhiteSpace:   trimWhiteSpace,
+						Trim:             trim,
+						OpticalCentering: opticalCentering,
+						MaskColor:        maskColor,
+						Resize:           resize,
+						Output:           output,
+					},
+				)
+			})
+		})
+	}
+
+	usageText := `targets [command options] <image path>
+
+examples:
+	tg --spec "./targets.json" "./logo.png"
+	tg --spec "./targets.yaml" --color "#0000FF" -p 0.1 --trim "./logo.png"
+	tg --spec "./targets.json" --zip "stores.zip" "./logo.png"
+	tg --spec "./targets.yaml" --watch "./logo.png"
+	tg --print-builtin > targets.json`
+
+	return &cli.Command{
+		Name:      "targets",
+		Aliases:   []string{"tg"},
+		UsageText: usageText,
+		Usage:     "Generate the custom targets of a JSON or YAML spec file, e.g: the icons of other app stores",
+		Action:    action,
+		Arguments: []cli.Argument{
+			imageArg(&imagePath),
+		},
+		Flags: slices.Concat(
+			[]cli.Flag{
+				targetSpecFlagFn("spec", &specPath),
+				&cli.BoolFlag{
+					Name:        "print-builtin",
+					Value:       false,
+					Usage:       "Print a description of every built-in target in the spec format, as a starting point for a spec",
+					Destination: &printBuiltin,
+				},
+				paddingFlagFn(&padding),
+				opticalCenterFlagFn(&opticalCentering),
+				alphaThresholdFlagFn(&alphaThreshold),
+			},
+			bgIconFlags(&bg),
+			resizeFlags(&resize),
+			[]cli.Flag{
+				trimWhiteSpaceFlagFn(&trimWhiteSpace),
+			},
+			trimFlags(&trim),
+			[]cli.Flag{
+				maskColorFlagFn(&maskColor),
+				zipFlagFn(&zipPath),
+				watchFlagFn(&watch),
+			},
+		),
+	}
+}
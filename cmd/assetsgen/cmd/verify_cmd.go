@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/Nidal-Bakir/assets-gen/assetsgen"
+	"github.com/urfave/cli/v3"
+)
+
+func Verify() *cli.Command {
+	var imagePath string
+
+	options := newAllOptions()
+	var tolerance float64
+
+	imageArg := imageArg(&imagePath)
+
+	action := func(ctx context.Context, c *cli.Command) error {
+		if b := isPathExist(imagePath); !b {
+			if len(imagePath) == 0 {
+				return ErrPleaseSpecifyImagePath
+			}
+			return assetsgen.ErrFileNotFound
+		}
+
+		src, err := assetsgen.LoadSourceImage(imagePath)
+		if err != nil {
+			return err
+		}
+
+		tempDir, err := os.MkdirTemp("", "assetsgen-verify-*")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tempDir)
+
+		output, err := assetsgen.NewDirSink(tempDir)
+		if err != nil {
+			return err
+		}
+		err = errors.Join(options.generate(src, output), output.Close())
+		if err != nil {
+			return err
+		}
+
+		issues, err := verifyProject(tempDir, tolerance)
+		if err != nil {
+			return err
+		}
+
+		if len(issues) == 0 {
+			fmt.Println("the icons are up to date")
+			return nil
+		}
+
+		for _, issue := range issues {
+			fmt.Println(issue)
+		}
+		return fmt.Errorf("%w: %d issues", ErrIconsAreNotUpToDate, len(issues))
+	}
+
+	usageText := `verify [command options] <image path>
+
+examples:
+	verify "./master_image.png"
+	verify --trim --padding 0.1 --color "#3498db" --tolerance 4 "./master_image.png"`
+
+	return &cli.Command{
+		Name:      "verify",
+		UsageText: usageText,
+		Usage:     "Regenerate the icons with the same flags as the all command and check the project's icons are up to date. Exits with an error listing the stale, missing or extra files",
+		Action:    action,
+		Arguments: []cli.Argument{
+			imageArg,
+		},
+		Flags: slices.Concat(
+			options.flags(),
+			[]cli.Flag{
+				toleranceFlagFn(&tolerance),
+			},
+		),
+	}
+}
+
+// Compares the files generated in [generatedDir] with where --apply would move them to.
+// The platforms not found in the working directory are skipped, at least one should be found
+func verifyProject(generatedDir string, tolerance float64) ([]assetsgen.VerifyIssue, error) {
+	type target struct {
+		generated string
+		dir       func() (string, error)
+		options   assetsgen.VerifyOptions
+	}
+
+	targets := []target{
+		{
+			generated: filepath.Join(generatedDir, assetsgen.PlatformTypeAndroid, "res"),
+			dir:       getAndroidResDir,
+			options:   assetsgen.VerifyOptions{Tolerance: tolerance},
+		},
+		{
+			generated: filepath.Join(generatedDir, assetsgen.PlatformTypeAndroid, "main"),
+			dir:       getAndroidMainDir,
+			// the play store icon Android Studio generates
+			options: assetsgen.VerifyOptions{Tolerance: tolerance, ExtraPatterns: []string{"*-playstore.png"}},
+		},
+		{
+			generated: filepath.Join(generatedDir, assetsgen.PlatformTypeIos, "Assets.xcassets", "AppIcon.appiconset"),
+			dir: func() (string, error) {
+				xcassetsDir, err := getIosXcassets()
+				return filepath.Join(xcassetsDir, "AppIcon.appiconset"), err
+			},
+			// --apply replaces the whole folder
+			options: assetsgen.VerifyOptions{Tolerance: tolerance, Owned: true},
+		},
+	}
+
+	var issues []assetsgen.VerifyIssue
+	found := false
+	for _, t := range targets {
+		dir, err := t.dir()
+		if err != nil {
+			fmt.Println("skipping:", err)
+			continue
+		}
+		found = true
+
+		targetIssues, err := assetsgen.VerifyDir(t.generated, dir, t.options)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, targetIssues...)
+	}
+
+	if !found {
+		return nil, ErrDidNotFindTheProjectFolders
+	}
+	return issues, nil
+}
@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Nidal-Bakir/assets-gen/assetsgen"
+	"github.com/urfave/cli/v3"
+)
+
+const (
+	watchPollInterval = 250 * time.Millisecond
+	// how long the files should be left unchanged before regenerating, e.g: while an editor is still saving
+	watchDebounce = 500 * time.Millisecond
+)
+
+func watchFlagFn(watch *bool) *cli.BoolFlag {
+	return &cli.BoolFlag{
+		Name:        "watch",
+		Aliases:     []string{"w"},
+		Value:       false,
+		Usage:       "Keep running and regenerate (and apply) every time the image, the background image or the spec file changes. Prints the outputs that changed",
+		Destination: watch,
+	}
+}
+
+// The non empty paths
+func watchedFiles(paths ...string) []string {
+	return slices.DeleteFunc(paths, func(p string) bool { return p == "" })
+}
+
+// Runs [run] once, and with [watch] again every time one of the [files] changes until interrupted.
+// In watch mode the errors of [run] are printed and the watch goes on, e.g: for a half saved image
+func runWatching(ctx context.Context, watch bool, files []string, run func(ctx context.Context) error) error {
+	if !watch {
+		return run(ctx)
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	var previous map[string][sha256.Size]byte
+	runOnce := func() {
+		recorder := &outputRecorder{sums: map[string][sha256.Size]byte{}}
+		err := run(context.WithValue(ctx, outputRecorderKey{}, recorder))
+		if err != nil {
+			fmt.Println("error:", err)
+			return
+		}
+		printOutputsDiff(previous, recorder.sums)
+		previous = recorder.sums
+	}
+
+	runOnce()
+	fmt.Println("watching", strings.Join(files, ", "), "(ctrl+c to stop)")
+
+	stamps := statFiles(files)
+	var changedAt time.Time
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			current := statFiles(files)
+			if !maps.Equal(current, stamps) {
+				stamps = current
+				changedAt = now
+				continue
+			}
+			if !changedAt.IsZero() && now.Sub(changedAt) >= watchDebounce {
+				changedAt = time.Time{}
+				fmt.Println()
+				runOnce()
+			}
+		}
+	}
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// The missing files get the zero stamp, so deleting and restoring a file is a change too
+func statFiles(files []string) map[string]fileStamp {
+	stamps := make(map[string]fileStamp, len(files))
+	for _, f := range files {
+		if info, err := os.Stat(f); err == nil {
+			stamps[f] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		} else {
+			stamps[f] = fileStamp{}
+		}
+	}
+	return stamps
+}
+
+// The outputs that changed since the previous run, or the count of the outputs on the first run
+func printOutputsDiff(previous, current map[string][sha256.Size]byte) {
+	if previous == nil {
+		fmt.Println("generated", len(current), "files")
+		return
+	}
+
+	changed, unchanged := 0, 0
+	for _, name := range slices.Sorted(maps.Keys(current)) {
+		sum, found := previous[name]
+		switch {
+		case !found:
+			fmt.Println("+", name)
+			changed++
+		case sum != current[name]:
+			fmt.Println("~", name)
+			changed++
+		default:
+			unchanged++
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(previous)) {
+		if _, found := current[name]; !found {
+			fmt.Println("-", name)
+			changed++
+		}
+	}
+	fmt.Println(changed, "changed,", unchanged, "unchanged")
+}
+
+type outputRecorderKey struct{}
+
+// Records the hash of every file written through [outputRecorder.wrap], to tell which outputs changed between the runs
+type outputRecorder struct {
+	mu   sync.Mutex
+	sums map[string][sha256.Size]byte
+}
+
+func (r *outputRecorder) wrap(output assetsgen.OutputSink) assetsgen.OutputSink {
+	return recordingSink{output: output, recorder: r}
+}
+
+type recordingSink struct {
+	output   assetsgen.OutputSink
+	recorder *outputRecorder
+}
+
+func (s recordingSink) Create(name string) (io.WriteCloser, error) {
+	w, err := s.output.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingFile{WriteCloser: w, hash: sha256.New(), name: name, recorder: s.recorder}, nil
+}
+
+type recordingFile struct {
+	io.WriteCloser
+	hash     hash.Hash
+	name     string
+	recorder *outputRecorder
+}
+
+func (f *recordingFile) Write(p []byte) (int, error) {
+	f.hash.Write(p)
+	return f.WriteCloser.Write(p)
+}
+
+func (f *recordingFile) Close() error {
+	err := f.WriteCloser.Close()
+	if err != nil {
+		return err
+	}
+
+	f.recorder.mu.Lock()
+	defer f.recorder.mu.Unlock()
+	f.recorder.sums[f.name] = [sha256.Size]byte(f.hash.Sum(nil))
+	return nil
+}
+
+// Wraps the output with the recorder of [runWatching] if there is one in the context
+func withRecorder(ctx context.Context, output assetsgen.OutputSink, fn func(output assetsgen.OutputSink) error) error {
+	recorder, ok := ctx.Value(outputRecorderKey{}).(*outputRecorder)
+	if !ok {
+		return fn(output)
+	}
+
+	return orDefaultOutput(output, func(output assetsgen.OutputSink) error {
+		return fn(recorder.wrap(output))
+	})
+}
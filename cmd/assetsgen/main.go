@@ -24,6 +24,11 @@ func main() {
 			cmd.IosAppIcon(),
 			cmd.AndroidGooglePlayLogo(),
 			cmd.GenerateAll(),
+			cmd.Preview(),
+			cmd.Verify(),
+			cmd.Lint(),
+			cmd.Targets(),
+			cmd.Serve(),
 		},
 	}
 
@@ -32,5 +37,6 @@ func main() {
 		log.Fatal(err)
 	}
 
-	fmt.Printf("\ntook %.2fsec\n", time.Since(startTime).Seconds())
+	// on stderr to keep the output of the commands that print to stdout usable, e.g: targets --print-builtin
+	fmt.Fprintf(os.Stderr, "\ntook %.2fsec\n", time.Since(startTime).Seconds())
 }
@@ -8,4 +8,7 @@ require (
 	github.com/urfave/cli/v3 v3.2.0
 )
 
-require golang.org/x/image v0.18.0 // indirect
+require (
+	golang.org/x/image v0.18.0
+	gopkg.in/yaml.v3 v3.0.1
+)